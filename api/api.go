@@ -0,0 +1,312 @@
+// SPDX-License-Identifier: Apache-2.0
+
+package api
+
+import (
+	"context"
+	"crypto/subtle"
+	"database/sql"
+	"encoding/json"
+	"fmt"
+	"log"
+	"net/http"
+	"os"
+	"radicle-cloud/db"
+	"radicle-cloud/metrics"
+	"strings"
+	"sync/atomic"
+	"time"
+)
+
+var l *log.Logger
+
+func init() {
+	l = log.New(os.Stderr, "[API]	", log.Ldate|log.Ltime|log.Lshortfile)
+}
+
+// Deployment is the JSON representation of a row in deployments
+type Deployment struct {
+	Org        string `json:"org"`
+	Expiry     uint64 `json:"expiry"`
+	Provider   string `json:"provider"`
+	IP         string `json:"ip"`
+	Status     string `json:"status"`
+	ServerID   string `json:"serverId"`
+	StartBlock uint64 `json:"startBlock"`
+}
+
+// Lifetime is the JSON representation of a row in deployment_history
+type Lifetime struct {
+	Provider     string    `json:"provider"`
+	IP           string    `json:"ip"`
+	ServerID     string    `json:"serverId"`
+	StartBlock   uint64    `json:"startBlock"`
+	EndBlock     uint64    `json:"endBlock"`
+	Reason       string    `json:"reason"`
+	StartedAt    time.Time `json:"startedAt"`
+	TerminatedAt time.Time `json:"terminatedAt"`
+}
+
+// Event is the JSON representation of a row in events
+type Event struct {
+	Type       string `json:"type"`
+	BlockAndTx string `json:"blockAndTx"`
+	LogIndex   uint   `json:"logIndex"`
+	EmittedAt  uint64 `json:"emittedAt"`
+	Expiry     uint64 `json:"expiry"`
+	Processed  bool   `json:"processed"`
+	Removed    bool   `json:"removed"`
+	Source     string `json:"source"`
+}
+
+// FailedEvent is the JSON representation of a row in failed_events
+type FailedEvent struct {
+	ID            int64      `json:"id"`
+	Org           string     `json:"org"`
+	Event         Event      `json:"event"`
+	Error         string     `json:"error"`
+	Attempts      int        `json:"attempts"`
+	Dead          bool       `json:"dead"`
+	NextAttemptAt *time.Time `json:"nextAttemptAt"`
+	UpdatedAt     time.Time  `json:"updatedAt"`
+}
+
+// Transition is the JSON representation of a row in deployment_transitions
+type Transition struct {
+	From      string    `json:"from"`
+	To        string    `json:"to"`
+	Reason    string    `json:"reason"`
+	CreatedAt time.Time `json:"createdAt"`
+}
+
+// DeploymentDetail is a deployment along with all of its events, its owners
+// by source, its transitions and its earlier lifetimes
+type DeploymentDetail struct {
+	Deployment
+	Owners      map[string]string `json:"owners"`
+	Events      []Event           `json:"events"`
+	Transitions []Transition      `json:"transitions"`
+	History     []Lifetime        `json:"history"`
+}
+
+// Contract is the current rate and owner of a source's contract
+type Contract struct {
+	Source       string `json:"source"`
+	RatePerBlock uint64 `json:"ratePerBlock"`
+	Owner        string `json:"owner"`
+}
+
+// Block is the block the operator measures expiries against
+type Block struct {
+	Current uint64 `json:"current"`
+}
+
+type server struct {
+	currentBlock *uint64
+	token        string
+}
+
+// Serve starts the admin HTTP server on addr until ctx is done, requests must
+// carry token as a bearer token unless it is empty
+func Serve(ctx context.Context, addr string, token string, currentBlock *uint64) error {
+	s := &server{currentBlock: currentBlock, token: token}
+	mux := http.NewServeMux()
+	mux.HandleFunc("/deployments", s.auth(s.listDeployments))
+	mux.HandleFunc("/deployments/", s.auth(s.getDeployment))
+	mux.HandleFunc("/block", s.auth(s.getBlock))
+	mux.HandleFunc("/contracts", s.auth(s.listContracts))
+	mux.HandleFunc("/failed-events", s.auth(s.listFailedEvents))
+	mux.HandleFunc("/metrics", s.auth(metrics.Handler().ServeHTTP))
+	srv := &http.Server{Addr: addr, Handler: mux}
+	go func() {
+		<-ctx.Done()
+		shutdownCtx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+		defer cancel()
+		if err := srv.Shutdown(shutdownCtx); err != nil {
+			l.Println("Failed to shutdown admin API", err)
+		}
+	}()
+	l.Println("Admin API listening on", addr)
+	if err := srv.ListenAndServe(); err != http.ErrServerClosed {
+		return err
+	}
+	return nil
+}
+
+func (s *server) auth(next http.HandlerFunc) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		if r.Method != http.MethodGet {
+			writeError(w, http.StatusMethodNotAllowed, fmt.Errorf("method %s not allowed", r.Method))
+			return
+		}
+		if s.token != "" {
+			given := strings.TrimPrefix(r.Header.Get("Authorization"), "Bearer ")
+			if subtle.ConstantTimeCompare([]byte(given), []byte(s.token)) != 1 {
+				writeError(w, http.StatusUnauthorized, fmt.Errorf("invalid token"))
+				return
+			}
+		}
+		next(w, r)
+	}
+}
+
+func (s *server) listDeployments(w http.ResponseWriter, r *http.Request) {
+	deps, err := db.ListDeployments(r.Context())
+	if err != nil {
+		l.Println("Failed to list deployments", err)
+		writeError(w, http.StatusInternalServerError, err)
+		return
+	}
+	status := r.URL.Query().Get("status")
+	res := []Deployment{}
+	for _, dep := range deps {
+		if status == "" || dep.Status == status {
+			res = append(res, toDeployment(dep))
+		}
+	}
+	writeJSON(w, http.StatusOK, res)
+}
+
+func (s *server) getDeployment(w http.ResponseWriter, r *http.Request) {
+	org := strings.ToLower(strings.TrimPrefix(r.URL.Path, "/deployments/"))
+	dep, err := db.GetDeployment(r.Context(), org)
+	if err == sql.ErrNoRows {
+		writeError(w, http.StatusNotFound, fmt.Errorf("no deployment for org %s", org))
+		return
+	}
+	if err != nil {
+		l.Println("Failed to get deployment for org", org, err)
+		writeError(w, http.StatusInternalServerError, err)
+		return
+	}
+	events, err := db.ListEvents(r.Context(), org)
+	if err != nil {
+		l.Println("Failed to list events for org", org, err)
+		writeError(w, http.StatusInternalServerError, err)
+		return
+	}
+
+	owners, err := db.DeploymentOwners(r.Context(), org)
+	if err != nil {
+		l.Println("Failed to list owners for org", org, err)
+		writeError(w, http.StatusInternalServerError, err)
+		return
+	}
+
+	transitions, err := db.ListTransitions(r.Context(), org)
+	if err != nil {
+		l.Println("Failed to list transitions for org", org, err)
+		writeError(w, http.StatusInternalServerError, err)
+		return
+	}
+
+	history, err := db.ListHistory(r.Context(), org)
+	if err != nil {
+		l.Println("Failed to list history for org", org, err)
+		writeError(w, http.StatusInternalServerError, err)
+		return
+	}
+
+	res := DeploymentDetail{Deployment: toDeployment(dep), Owners: owners, Events: []Event{}, Transitions: []Transition{}, History: []Lifetime{}}
+	for _, h := range history {
+		res.History = append(res.History, Lifetime{
+			Provider:     h.Provider,
+			IP:           h.IP,
+			ServerID:     h.ServerID,
+			StartBlock:   h.StartBlock,
+			EndBlock:     h.EndBlock,
+			Reason:       h.Reason,
+			StartedAt:    h.StartedAt,
+			TerminatedAt: h.TerminatedAt,
+		})
+	}
+	for _, t := range transitions {
+		res.Transitions = append(res.Transitions, Transition{From: t.From, To: t.To, Reason: t.Reason, CreatedAt: t.CreatedAt})
+	}
+	for _, e := range events {
+		res.Events = append(res.Events, Event{
+			Type:       e.Type,
+			BlockAndTx: fmt.Sprintf("0x%x", e.BlockAndTx),
+			LogIndex:   e.LogIndex,
+			EmittedAt:  e.EmittedAt,
+			Expiry:     e.Expiry,
+			Processed:  e.Processed,
+			Removed:    e.Removed,
+			Source:     e.Source,
+		})
+	}
+	writeJSON(w, http.StatusOK, res)
+}
+
+func (s *server) listFailedEvents(w http.ResponseWriter, r *http.Request) {
+	failures, err := db.ListFailures(r.Context(), r.URL.Query().Get("dead") == "true")
+	if err != nil {
+		l.Println("Failed to list failed events", err)
+		writeError(w, http.StatusInternalServerError, err)
+		return
+	}
+	res := []FailedEvent{}
+	for _, f := range failures {
+		res = append(res, FailedEvent{
+			ID:  f.ID,
+			Org: f.Event.Org,
+			Event: Event{
+				Type:       f.Event.Type.String(),
+				BlockAndTx: fmt.Sprintf("0x%x", f.Event.BlockAndTx),
+				LogIndex:   f.Event.LogIndex,
+				EmittedAt:  f.Event.BlockNumber,
+				Expiry:     f.Event.Expiry,
+				Removed:    f.Event.Removed,
+				Source:     f.Event.Source,
+			},
+			Error:         f.Error,
+			Attempts:      f.Attempts,
+			Dead:          f.Dead,
+			NextAttemptAt: f.NextAttemptAt,
+			UpdatedAt:     f.UpdatedAt,
+		})
+	}
+	writeJSON(w, http.StatusOK, res)
+}
+
+func (s *server) listContracts(w http.ResponseWriter, r *http.Request) {
+	contracts, err := db.ListContracts(r.Context())
+	if err != nil {
+		l.Println("Failed to list contracts", err)
+		writeError(w, http.StatusInternalServerError, err)
+		return
+	}
+	res := []Contract{}
+	for _, c := range contracts {
+		res = append(res, Contract{Source: c.Source, RatePerBlock: c.Rate, Owner: c.Owner})
+	}
+	writeJSON(w, http.StatusOK, res)
+}
+
+func (s *server) getBlock(w http.ResponseWriter, r *http.Request) {
+	writeJSON(w, http.StatusOK, Block{Current: atomic.LoadUint64(s.currentBlock)})
+}
+
+func toDeployment(dep db.Dep) Deployment {
+	return Deployment{
+		Org:        dep.Org,
+		Expiry:     dep.Expiry,
+		Provider:   dep.Provider,
+		IP:         dep.IP,
+		Status:     dep.Status,
+		ServerID:   dep.ServerID,
+		StartBlock: dep.StartBlock,
+	}
+}
+
+func writeJSON(w http.ResponseWriter, code int, v interface{}) {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(code)
+	if err := json.NewEncoder(w).Encode(v); err != nil {
+		l.Println("Failed to write response", err)
+	}
+}
+
+func writeError(w http.ResponseWriter, code int, err error) {
+	writeJSON(w, code, map[string]string{"error": err.Error()})
+}
@@ -9,6 +9,8 @@ import (
 	"log"
 	"math/big"
 	"os"
+	"radicle-cloud/metrics"
+	"regexp"
 	"time"
 
 	"github.com/apenella/go-ansible/pkg/options"
@@ -16,45 +18,78 @@ import (
 )
 
 var l *log.Logger
-var termFns map[string]func(string) error
+
+// orgRegexp matches an org address as used for server names and subdomains
+var orgRegexp = regexp.MustCompile(`^0x[0-9a-f]{40}$`)
 
 func init() {
 	l = log.New(os.Stderr, "[CLOUD]	", log.Ldate|log.Ltime|log.Lshortfile)
-
-	termFns = map[string]func(string) error{
-		"hetzner": hetznerDeleteServer,
-	}
 }
 
 // Setup different cloud providers
 func Setup() {
-	hetznerSetup()
-	cloudflareSetup()
+	providersSetup()
+	dnsSetup()
+}
+
+// IsOrg reports whether name is an org address, servers and subdomains of
+// orgs are named after it
+func IsOrg(name string) bool {
+	return orgRegexp.MatchString(name)
 }
 
 // ReserveServer reserves a VPS randomly from a provider
-func ReserveServer(org string) (string, string, error) {
-	labels := []string{"hetzner"}
-	providers := []func(string) (string, error){hetznerCreateServer}
+func ReserveServer(ctx context.Context, org string) (string, string, error) {
+	providers := Providers()
+	if len(providers) == 0 {
+		return "", "", fmt.Errorf("no cloud provider is enabled")
+	}
 	pickBn, err := rand.Int(rand.Reader, big.NewInt(int64(len(providers))))
 	if err != nil {
 		l.Fatalln(err)
 	}
-	pick := pickBn.Int64()
-	ip, err := providers[pick](org)
-	return labels[pick], ip, err
+	p := providers[pickBn.Int64()]
+	start := time.Now()
+	ip, err := p.Create(ctx, org)
+	observeProvider(p.Name(), "create", start, err)
+	return p.Name(), ip, err
+}
+
+// ServerID returns the id of org's server at provider
+func ServerID(ctx context.Context, org string, provider string) (string, error) {
+	p, ok := GetProvider(provider)
+	if !ok {
+		return "", fmt.Errorf("provider %s is not enabled", provider)
+	}
+	start := time.Now()
+	srv, err := p.Get(ctx, org)
+	observeProvider(provider, "get", start, err)
+	if err != nil {
+		return "", err
+	}
+	if srv == nil {
+		return "", fmt.Errorf("no server for org %s at %s", org, provider)
+	}
+	return srv.ID, nil
 }
 
 // TerminateOrg cleans up resources that's been created for org
-func TerminateOrg(org string, provider string) bool {
+func TerminateOrg(ctx context.Context, org string, provider string) bool {
 	// terminate the server
-	fn := termFns[provider]
-	if err := fn(org); err != nil {
+	p, ok := GetProvider(provider)
+	if !ok {
+		l.Printf("Provider %s of org %s is not enabled\n", provider, org)
+		return false
+	}
+	start := time.Now()
+	err := p.Delete(ctx, org)
+	observeProvider(provider, "delete", start, err)
+	if err != nil {
 		return false
 	}
 
 	// delete dns record
-	if err := DeleteDNS(org); err != nil {
+	if err := DeleteDNS(ctx, org); err != nil {
 		return false
 	}
 
@@ -62,7 +97,7 @@ func TerminateOrg(org string, provider string) bool {
 }
 
 // RunAnsible runs the initial setup playbook on the newly spawned server
-func RunAnsible(org string, ip string, retries int) error {
+func RunAnsible(ctx context.Context, org string, ip string, retries int) error {
 	sshKeyPath := os.Getenv("LOCAL_SSH_PATH")
 	ansiblePlaybookConnectionOptions := &options.AnsibleConnectionOptions{
 		User:         "root",
@@ -74,7 +109,7 @@ func RunAnsible(org string, ip string, retries int) error {
 			"RAD_ORG":      org,
 			"RAD_RPC_URL":  os.Getenv("RAD_RPC_URL"),
 			"RAD_SUBGRAPH": os.Getenv("RAD_SUBGRAPH"),
-			"RAD_DOMAIN":   os.Getenv("CLOUDFLARE_DOMAIN"),
+			"RAD_DOMAIN":   ourDomain,
 		},
 	}
 	playbook := &playbook.AnsiblePlaybookCmd{
@@ -83,13 +118,25 @@ func RunAnsible(org string, ip string, retries int) error {
 		Options:           ansiblePlaybookOptions,
 		//StdoutCallback:    "json",
 	}
-	if err := playbook.Run(context.TODO()); err != nil {
+	start := time.Now()
+	err := playbook.Run(ctx)
+	metrics.AnsibleDuration.WithLabelValues(metrics.Result(err)).Observe(time.Since(start).Seconds())
+	if err != nil {
 		l.Println("Error running ansible", err, "retries left", retries)
 		if retries > 0 {
-			time.Sleep(time.Second * 5)
-			return RunAnsible(org, ip, retries-1)
+			metrics.AnsibleRetries.Inc()
+			select {
+			case <-ctx.Done():
+				return ctx.Err()
+			case <-time.After(time.Second * 5):
+			}
+			return RunAnsible(ctx, org, ip, retries-1)
 		}
 		return err
 	}
 	return nil
 }
+
+func observeProvider(provider string, op string, start time.Time, err error) {
+	metrics.ProviderDuration.WithLabelValues(provider, op, metrics.Result(err)).Observe(time.Since(start).Seconds())
+}
@@ -10,57 +10,92 @@ import (
 	"github.com/cloudflare/cloudflare-go"
 )
 
-var api *cloudflare.API
-var zoneID string
-var ourDomain string
+// recordExistsCode is returned by cloudflare when creating a duplicate record
+const recordExistsCode = 81057
 
-func cloudflareSetup() {
+type cloudflareDNS struct {
+	api    *cloudflare.API
+	zoneID string
+}
+
+func init() {
+	RegisterDNS(&cloudflareDNS{})
+}
+
+func (c *cloudflareDNS) Name() string {
+	return "cloudflare"
+}
+
+func (c *cloudflareDNS) Setup(domain string) error {
 	var err error
 	apiToken := os.Getenv("CLOUDFLARE_API_TOKEN")
-	api, err = cloudflare.NewWithAPIToken(apiToken)
+	c.api, err = cloudflare.NewWithAPIToken(apiToken)
 	if err != nil {
-		l.Fatal(err)
+		return err
 	}
 
-	ourDomain = os.Getenv("CLOUDFLARE_DOMAIN")
-	zoneID, err = api.ZoneIDByName(ourDomain)
-	if err != nil {
-		l.Fatal(err)
-	}
+	c.zoneID, err = c.api.ZoneIDByName(domain)
+	return err
 }
 
-// CreateDNS creates an A record for org.ourdomain.tld
-func CreateDNS(org string, ip string) error {
+// Create replaces the address of an existing record of fqdn, so that a record
+// created again for a new server doesn't keep pointing at the old one
+func (c *cloudflareDNS) Create(ctx context.Context, fqdn string, ip string) error {
 	proxied := false
-	_, err := api.CreateDNSRecord(context.Background(), zoneID, cloudflare.DNSRecord{
+	record := cloudflare.DNSRecord{
 		Type:    "A",
-		Name:    fqdn(org),
+		Name:    fqdn,
 		Content: ip,
 		TTL:     3600,
 		Proxied: &proxied,
-	})
-	return err
+	}
+	_, err := c.api.CreateDNSRecord(ctx, c.zoneID, record)
+	if err == nil || err.Error() != fmt.Sprintf("HTTP status 400: Record already exists. (%d)", recordExistsCode) {
+		return err
+	}
+	records, err := c.api.DNSRecords(ctx, c.zoneID, cloudflare.DNSRecord{Type: "A", Name: fqdn})
+	if err != nil {
+		return err
+	}
+	if len(records) == 0 {
+		return fmt.Errorf("record of %s exists but isn't an A record", fqdn)
+	}
+	return c.api.UpdateDNSRecord(ctx, c.zoneID, records[0].ID, record)
 }
 
-// DeleteDNS deletes the A record for org.ourdomain.tld
-func DeleteDNS(org string) error {
-	dnsID, err := getDNSID(org)
+func (c *cloudflareDNS) Delete(ctx context.Context, fqdn string) error {
+	dnsID, err := c.getDNSID(ctx, fqdn)
 	if err != nil {
 		return err
 	}
-	return api.DeleteDNSRecord(context.Background(), zoneID, dnsID)
+	// record did not exist, consider it a re-try which had succeeded
+	if dnsID == "" {
+		return nil
+	}
+	return c.api.DeleteDNSRecord(ctx, c.zoneID, dnsID)
 }
 
-func getDNSID(org string) (string, error) {
-	filter := cloudflare.DNSRecord{Name: fqdn(org)}
-	records, err := api.DNSRecords(context.Background(), zoneID, filter)
+func (c *cloudflareDNS) List(ctx context.Context) ([]Record, error) {
+	records, err := c.api.DNSRecords(ctx, c.zoneID, cloudflare.DNSRecord{Type: "A"})
+	if err != nil {
+		return nil, err
+	}
+	rs := make([]Record, 0, len(records))
+	for _, r := range records {
+		rs = append(rs, Record{ID: r.ID, Name: r.Name, IP: r.Content})
+	}
+	return rs, nil
+}
+
+func (c *cloudflareDNS) getDNSID(ctx context.Context, fqdn string) (string, error) {
+	filter := cloudflare.DNSRecord{Name: fqdn}
+	records, err := c.api.DNSRecords(ctx, c.zoneID, filter)
 	if err != nil {
 		return "", err
 	}
+	if len(records) == 0 {
+		return "", nil
+	}
 
 	return records[0].ID, nil
 }
-
-func fqdn(org string) string {
-	return fmt.Sprintf("%s.%s", org, ourDomain)
-}
@@ -0,0 +1,105 @@
+// SPDX-License-Identifier: Apache-2.0
+
+package cloud
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"radicle-cloud/metrics"
+)
+
+// Record is an A record in our zone
+type Record struct {
+	ID   string
+	Name string
+	IP   string
+}
+
+// DNSProvider manages A records of org subdomains in our zone
+type DNSProvider interface {
+	// Name is the value of DNS_PROVIDER selecting this provider
+	Name() string
+	// Setup reads configuration and connects to the DNS server or API
+	Setup(domain string) error
+	// Create adds an A record for fqdn or replaces the address of an existing
+	// one
+	Create(ctx context.Context, fqdn string, ip string) error
+	// Delete removes the A record of fqdn
+	Delete(ctx context.Context, fqdn string) error
+	// List returns all A records in the zone
+	List(ctx context.Context) ([]Record, error)
+}
+
+var dnsProviders = map[string]DNSProvider{}
+var dnsProvider DNSProvider
+var ourDomain string
+
+// RegisterDNS makes a DNS provider available, it should be called from init
+func RegisterDNS(p DNSProvider) {
+	if _, ok := dnsProviders[p.Name()]; ok {
+		l.Fatalf("DNS provider %s registered twice\n", p.Name())
+	}
+	dnsProviders[p.Name()] = p
+}
+
+func dnsSetup() {
+	name := os.Getenv("DNS_PROVIDER")
+	if name == "" {
+		name = "cloudflare"
+	}
+	p, ok := dnsProviders[name]
+	if !ok {
+		l.Fatalf("Unknown DNS provider %s\n", name)
+	}
+
+	ourDomain = os.Getenv("DNS_DOMAIN")
+	if ourDomain == "" {
+		ourDomain = os.Getenv("CLOUDFLARE_DOMAIN")
+	}
+	if err := p.Setup(ourDomain); err != nil {
+		l.Fatalf("Failed to setup DNS provider %s: %v\n", name, err)
+	}
+	dnsProvider = p
+	l.Println("Enabled DNS provider", name, "for", ourDomain)
+}
+
+// Domain returns the zone org subdomains are created in
+func Domain() string {
+	return ourDomain
+}
+
+// CreateDNS creates an A record for org.ourdomain.tld
+func CreateDNS(ctx context.Context, org string, ip string) error {
+	err := dnsProvider.Create(ctx, fqdn(org), ip)
+	if err != nil {
+		countDNSError("create")
+	}
+	return err
+}
+
+// DeleteDNS deletes the A record for org.ourdomain.tld
+func DeleteDNS(ctx context.Context, org string) error {
+	err := dnsProvider.Delete(ctx, fqdn(org))
+	if err != nil {
+		countDNSError("delete")
+	}
+	return err
+}
+
+// ListDNS lists all A records in our zone
+func ListDNS(ctx context.Context) ([]Record, error) {
+	records, err := dnsProvider.List(ctx)
+	if err != nil {
+		countDNSError("list")
+	}
+	return records, err
+}
+
+func countDNSError(op string) {
+	metrics.DNSErrors.WithLabelValues(dnsProvider.Name(), op).Inc()
+}
+
+func fqdn(org string) string {
+	return fmt.Sprintf("%s.%s", org, ourDomain)
+}
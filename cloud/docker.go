@@ -0,0 +1,159 @@
+// SPDX-License-Identifier: Apache-2.0
+
+package cloud
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strings"
+)
+
+// docker reserves "servers" as containers on the local Docker daemon, which
+// makes it possible to run the whole pipeline offline
+type docker struct {
+	image   string
+	pubKey  string
+	network string
+}
+
+func init() {
+	Register(&docker{})
+}
+
+func (d *docker) Name() string {
+	return "docker"
+}
+
+func (d *docker) Setup() error {
+	d.image = os.Getenv("DOCKER_IMAGE")
+	if d.image == "" {
+		d.image = "radicle-cloud-host:latest"
+	}
+	d.network = os.Getenv("DOCKER_NETWORK")
+	if d.network == "" {
+		d.network = "bridge"
+	}
+	// public half of LOCAL_SSH_PATH is authorized in containers for ansible
+	sshKeyPath := os.Getenv("LOCAL_SSH_PATH")
+	if strings.HasPrefix(sshKeyPath, "~/") {
+		home, err := os.UserHomeDir()
+		if err != nil {
+			return err
+		}
+		sshKeyPath = filepath.Join(home, sshKeyPath[2:])
+	}
+	d.pubKey = sshKeyPath + ".pub"
+	if _, err := os.Stat(d.pubKey); err != nil {
+		return err
+	}
+	_, err := d.run(context.Background(), "version", "--format", "{{.Server.Version}}")
+	return err
+}
+
+func (d *docker) Create(ctx context.Context, org string) (string, error) {
+	srv, err := d.Get(ctx, org)
+	if err != nil {
+		return "", err
+	}
+	if srv != nil {
+		l.Printf("Container for org %s already reserved\n", org)
+		return srv.IP, nil
+	}
+
+	_, err = d.run(
+		ctx, "run", "--detach", "--privileged",
+		"--name", containerName(org),
+		"--label", fmt.Sprintf("%s=%s", orgLabel, org),
+		"--network", d.network,
+		"--volume", fmt.Sprintf("%s:/root/.ssh/authorized_keys:ro", d.pubKey),
+		d.image,
+	)
+	if err != nil {
+		return "", err
+	}
+	l.Printf("Container for org %s is running.\n", org)
+
+	if srv, err = d.Get(ctx, org); err != nil {
+		return "", err
+	}
+	if srv == nil || srv.IP == "" {
+		return "", fmt.Errorf("container for %s has no ip on network %s", org, d.network)
+	}
+	return srv.IP, nil
+}
+
+func (d *docker) Get(ctx context.Context, org string) (*Server, error) {
+	out, err := d.run(ctx, "ps", "--all", "--quiet", "--filter", fmt.Sprintf("name=^/?%s$", containerName(org)))
+	if err != nil || out == "" {
+		return nil, err
+	}
+	return d.inspect(ctx, out)
+}
+
+func (d *docker) Delete(ctx context.Context, org string) error {
+	srv, err := d.Get(ctx, org)
+	if err != nil {
+		return err
+	}
+	// container did not exist, consider it a re-try which had succeeded
+	if srv == nil {
+		return nil
+	}
+	_, err = d.run(ctx, "rm", "--force", "--volumes", srv.ID)
+	return err
+}
+
+func (d *docker) List(ctx context.Context) ([]Server, error) {
+	out, err := d.run(ctx, "ps", "--all", "--quiet", "--filter", "label="+orgLabel)
+	if err != nil {
+		return nil, err
+	}
+	servers := []Server{}
+	for _, id := range strings.Fields(out) {
+		srv, err := d.inspect(ctx, id)
+		if err != nil {
+			return nil, err
+		}
+		servers = append(servers, *srv)
+	}
+	return servers, nil
+}
+
+func (d *docker) inspect(ctx context.Context, id string) (*Server, error) {
+	format := fmt.Sprintf(
+		`{{.Id}} {{index .Config.Labels %q}} {{with index .NetworkSettings.Networks %q}}{{.IPAddress}}{{end}}`,
+		orgLabel, d.network,
+	)
+	out, err := d.run(ctx, "inspect", "--format", format, id)
+	if err != nil {
+		return nil, err
+	}
+	fields := strings.Fields(out)
+	if len(fields) < 2 {
+		return nil, fmt.Errorf("container %s is not managed by the operator", id)
+	}
+	srv := &Server{ID: fields[0], Org: fields[1]}
+	if len(fields) > 2 {
+		srv.IP = fields[2]
+	}
+	return srv, nil
+}
+
+func (d *docker) run(ctx context.Context, args ...string) (string, error) {
+	var stdout, stderr bytes.Buffer
+	cmd := exec.CommandContext(ctx, "docker", args...)
+	cmd.Stdout = &stdout
+	cmd.Stderr = &stderr
+	if err := cmd.Run(); err != nil {
+		return "", fmt.Errorf("docker %s: %v: %s", args[0], err, strings.TrimSpace(stderr.String()))
+	}
+	return strings.TrimSpace(stdout.String()), nil
+}
+
+func containerName(org string) string {
+	return "radicle-cloud-" + org
+}
@@ -6,38 +6,51 @@ import (
 	"context"
 	"fmt"
 	"os"
+	"strconv"
 	"time"
 
 	"github.com/hetznercloud/hcloud-go/hcloud"
 )
 
-var client *hcloud.Client
-var key *hcloud.SSHKey
+// orgLabel marks servers created by the operator so List can skip others
+const orgLabel = "radicle-cloud-org"
 
-func hetznerSetup() {
+type hetzner struct {
+	client *hcloud.Client
+	key    *hcloud.SSHKey
+}
+
+func init() {
+	Register(&hetzner{})
+}
+
+func (h *hetzner) Name() string {
+	return "hetzner"
+}
+
+func (h *hetzner) Setup() error {
 	token := os.Getenv("HETNZER_TOKEN")
 	sshKeyName := os.Getenv("HETNZER_SSH_NAME")
-	client = hcloud.NewClient(hcloud.WithToken(token))
+	h.client = hcloud.NewClient(hcloud.WithToken(token))
 	var err error
-	key, _, err = client.SSHKey.GetByName(context.Background(), sshKeyName)
-	if err != nil {
-		panic(err)
-	}
+	h.key, _, err = h.client.SSHKey.GetByName(context.Background(), sshKeyName)
+	return err
 }
 
-func hetznerCreateServer(org string) (string, error) {
-	srvCreateResult, _, err := client.Server.Create(context.Background(), hcloud.ServerCreateOpts{
+func (h *hetzner) Create(ctx context.Context, org string) (string, error) {
+	srvCreateResult, _, err := h.client.Server.Create(ctx, hcloud.ServerCreateOpts{
 		Name:       org,
 		Image:      &hcloud.Image{Name: "docker-ce"},
 		ServerType: &hcloud.ServerType{Name: "cx11"},
-		SSHKeys:    []*hcloud.SSHKey{key},
+		SSHKeys:    []*hcloud.SSHKey{h.key},
+		Labels:     map[string]string{orgLabel: org},
 	})
 	if err != nil {
 		if hcloud.IsError(err, hcloud.ErrorCodeUniquenessError) {
 			l.Printf("Server for org %s already reserved\n", org)
 			// we already have the server so we simply return it
 			var srv *hcloud.Server
-			if srv, _, err = client.Server.GetByName(context.Background(), org); err != nil {
+			if srv, _, err = h.client.Server.GetByName(ctx, org); err != nil {
 				l.Printf("Failed to retrieve already reserved server for org %s\n", org)
 				return "", err
 			}
@@ -50,8 +63,12 @@ func hetznerCreateServer(org string) (string, error) {
 	counter := 0
 	srv := srvCreateResult.Server
 	for srv.Status != "running" {
-		time.Sleep(time.Second * 5)
-		srv, _, _ = client.Server.GetByID(context.Background(), srv.ID)
+		select {
+		case <-ctx.Done():
+			return "", ctx.Err()
+		case <-time.After(time.Second * 5):
+		}
+		srv, _, _ = h.client.Server.GetByID(ctx, srv.ID)
 		counter += 5
 		if counter > 60 {
 			return "", fmt.Errorf("timed out waiting for %s server to become \"running\"", org)
@@ -62,8 +79,17 @@ func hetznerCreateServer(org string) (string, error) {
 	return srv.PublicNet.IPv4.IP.String(), nil
 }
 
-func hetznerDeleteServer(org string) error {
-	srv, _, err := client.Server.GetByName(context.Background(), org)
+func (h *hetzner) Get(ctx context.Context, org string) (*Server, error) {
+	srv, _, err := h.client.Server.GetByName(ctx, org)
+	if err != nil || srv == nil {
+		return nil, err
+	}
+	s := hetznerServer(srv)
+	return &s, nil
+}
+
+func (h *hetzner) Delete(ctx context.Context, org string) error {
+	srv, _, err := h.client.Server.GetByName(ctx, org)
 	if err != nil {
 		return err
 	}
@@ -71,6 +97,30 @@ func hetznerDeleteServer(org string) error {
 	if srv == nil {
 		return nil
 	}
-	_, err = client.Server.Delete(context.Background(), srv)
+	_, err = h.client.Server.Delete(ctx, srv)
 	return err
 }
+
+func (h *hetzner) List(ctx context.Context) ([]Server, error) {
+	srvs, err := h.client.Server.All(ctx)
+	if err != nil {
+		return nil, err
+	}
+	servers := make([]Server, 0, len(srvs))
+	for _, srv := range srvs {
+		// servers created before orgLabel are only known by their name
+		if _, ok := srv.Labels[orgLabel]; !ok && !IsOrg(srv.Name) {
+			continue
+		}
+		servers = append(servers, hetznerServer(srv))
+	}
+	return servers, nil
+}
+
+func hetznerServer(srv *hcloud.Server) Server {
+	return Server{
+		ID:  strconv.Itoa(srv.ID),
+		Org: srv.Name,
+		IP:  srv.PublicNet.IPv4.IP.String(),
+	}
+}
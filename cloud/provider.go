@@ -0,0 +1,86 @@
+// SPDX-License-Identifier: Apache-2.0
+
+package cloud
+
+import (
+	"context"
+	"os"
+	"sort"
+	"strings"
+)
+
+// Server is a VPS reserved for an org at a provider
+type Server struct {
+	ID  string
+	Org string
+	IP  string
+}
+
+// Provider is a cloud provider capable of reserving servers for orgs
+type Provider interface {
+	// Name is the label stored in the deployments table
+	Name() string
+	// Setup reads configuration and connects to the provider API
+	Setup() error
+	// Create reserves a running server for org and returns its ip
+	Create(ctx context.Context, org string) (string, error)
+	// Get returns the server of org or nil if there's none
+	Get(ctx context.Context, org string) (*Server, error)
+	// Delete terminates the server of org, a missing server is not an error
+	Delete(ctx context.Context, org string) error
+	// List returns all servers reserved at the provider
+	List(ctx context.Context) ([]Server, error)
+}
+
+// registered holds every provider known at compile time
+var registered = map[string]Provider{}
+
+// enabled holds the providers selected by CLOUD_PROVIDERS
+var enabled = map[string]Provider{}
+
+// Register makes a provider available, it should be called from init
+func Register(p Provider) {
+	if _, ok := registered[p.Name()]; ok {
+		l.Fatalf("Provider %s registered twice\n", p.Name())
+	}
+	registered[p.Name()] = p
+}
+
+// GetProvider returns the enabled provider with name
+func GetProvider(name string) (Provider, bool) {
+	p, ok := enabled[name]
+	return p, ok
+}
+
+// Providers returns all enabled providers sorted by name
+func Providers() []Provider {
+	names := make([]string, 0, len(enabled))
+	for name := range enabled {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	ps := make([]Provider, 0, len(names))
+	for _, name := range names {
+		ps = append(ps, enabled[name])
+	}
+	return ps
+}
+
+func providersSetup() {
+	names := os.Getenv("CLOUD_PROVIDERS")
+	if names == "" {
+		names = "hetzner"
+	}
+	for _, name := range strings.Split(names, ",") {
+		name = strings.TrimSpace(name)
+		p, ok := registered[name]
+		if !ok {
+			l.Fatalf("Unknown cloud provider %s\n", name)
+		}
+		if err := p.Setup(); err != nil {
+			l.Fatalf("Failed to setup provider %s: %v\n", name, err)
+		}
+		enabled[name] = p
+		l.Println("Enabled cloud provider", name)
+	}
+}
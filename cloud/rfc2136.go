@@ -0,0 +1,139 @@
+// SPDX-License-Identifier: Apache-2.0
+
+package cloud
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"strings"
+	"time"
+
+	"github.com/miekg/dns"
+)
+
+// rfc2136 speaks dynamic DNS UPDATE signed with TSIG to a self-hosted
+// authoritative server such as BIND or Knot
+type rfc2136 struct {
+	server    string
+	zone      string
+	keyName   string
+	secret    string
+	algorithm string
+	ttl       uint32
+}
+
+func init() {
+	RegisterDNS(&rfc2136{})
+}
+
+func (r *rfc2136) Name() string {
+	return "rfc2136"
+}
+
+func (r *rfc2136) Setup(domain string) error {
+	r.server = os.Getenv("RFC2136_SERVER")
+	if r.server == "" {
+		return fmt.Errorf("RFC2136_SERVER is not set")
+	}
+	if !strings.Contains(r.server, ":") {
+		r.server += ":53"
+	}
+	r.zone = dns.Fqdn(domain)
+	r.keyName = dns.Fqdn(os.Getenv("RFC2136_TSIG_KEY"))
+	r.secret = os.Getenv("RFC2136_TSIG_SECRET")
+	r.algorithm = dns.Fqdn(os.Getenv("RFC2136_TSIG_ALGORITHM"))
+	if r.algorithm == "." {
+		r.algorithm = dns.HmacSHA256
+	}
+	r.ttl = 3600
+
+	// make sure we can reach the server and it is authoritative for the zone
+	m := new(dns.Msg)
+	m.SetQuestion(r.zone, dns.TypeSOA)
+	in, err := r.exchange(context.Background(), m)
+	if err != nil {
+		return err
+	}
+	if len(in.Answer) == 0 {
+		return fmt.Errorf("%s has no SOA for %s", r.server, r.zone)
+	}
+	return nil
+}
+
+// Create replaces the A records of fqdn in a single UPDATE, so that a record
+// created again for a new server doesn't leave the old address behind
+func (r *rfc2136) Create(ctx context.Context, fqdn string, ip string) error {
+	rr, err := dns.NewRR(fmt.Sprintf("%s %d IN A %s", dns.Fqdn(fqdn), r.ttl, ip))
+	if err != nil {
+		return err
+	}
+	rrset := &dns.A{Hdr: dns.RR_Header{Name: dns.Fqdn(fqdn), Rrtype: dns.TypeA, Class: dns.ClassINET}}
+	m := new(dns.Msg)
+	m.SetUpdate(r.zone)
+	m.RemoveRRset([]dns.RR{rrset})
+	m.Insert([]dns.RR{rr})
+	_, err = r.exchange(ctx, m)
+	return err
+}
+
+func (r *rfc2136) Delete(ctx context.Context, fqdn string) error {
+	rr := &dns.A{Hdr: dns.RR_Header{Name: dns.Fqdn(fqdn), Rrtype: dns.TypeA, Class: dns.ClassINET}}
+	m := new(dns.Msg)
+	m.SetUpdate(r.zone)
+	m.RemoveRRset([]dns.RR{rr})
+	_, err := r.exchange(ctx, m)
+	return err
+}
+
+func (r *rfc2136) List(ctx context.Context) ([]Record, error) {
+	m := new(dns.Msg)
+	m.SetAxfr(r.zone)
+	r.sign(m)
+	t := &dns.Transfer{}
+	if r.secret != "" {
+		t.TsigSecret = map[string]string{r.keyName: r.secret}
+	}
+	envelopes, err := t.In(m, r.server)
+	if err != nil {
+		return nil, err
+	}
+	records := []Record{}
+	for env := range envelopes {
+		if ctx.Err() != nil {
+			return nil, ctx.Err()
+		}
+		if env.Error != nil {
+			return nil, env.Error
+		}
+		for _, rr := range env.RR {
+			if a, ok := rr.(*dns.A); ok {
+				name := strings.TrimSuffix(a.Hdr.Name, ".")
+				records = append(records, Record{ID: name, Name: name, IP: a.A.String()})
+			}
+		}
+	}
+	return records, nil
+}
+
+func (r *rfc2136) exchange(ctx context.Context, m *dns.Msg) (*dns.Msg, error) {
+	r.sign(m)
+	c := &dns.Client{Timeout: 10 * time.Second}
+	if r.secret != "" {
+		c.TsigSecret = map[string]string{r.keyName: r.secret}
+	}
+	in, _, err := c.ExchangeContext(ctx, m, r.server)
+	if err != nil {
+		return nil, err
+	}
+	if in.Rcode != dns.RcodeSuccess {
+		return nil, fmt.Errorf("%s answered %s", r.server, dns.RcodeToString[in.Rcode])
+	}
+	return in, nil
+}
+
+func (r *rfc2136) sign(m *dns.Msg) {
+	if r.secret != "" {
+		m.SetTsig(r.keyName, r.algorithm, 300, time.Now().Unix())
+	}
+}
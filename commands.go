@@ -0,0 +1,193 @@
+// SPDX-License-Identifier: Apache-2.0
+
+package main
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"os"
+	"radicle-cloud/db"
+	"strconv"
+	"strings"
+	"text/tabwriter"
+)
+
+const usage = `Usage: radicle-cloud [command]
+
+Without a command the operator is started.
+
+Commands:
+  migrate status         show applied and pending migrations
+  migrate up             apply pending migrations
+  migrate down [steps]   rollback the latest applied migrations, default 1
+  failed [--dead]        list events which failed processing
+  requeue <id>           retry a failed event right away
+  history <org>          show every lifetime and transition of a deployment
+  replay [flags]         re-read contract events and compare expiries with DB
+    --from <block>       first block, defaults to the start block of each source
+    --to <block>         last block, defaults to the latest confirmed block
+    --source <source>    replay only this chain:address
+    --page <blocks>      blocks fetched per eth_getLogs call, default 2000
+    --store              store the replayed events
+    --provision          store them and provision missing deployments
+`
+
+func runCommand(args []string) {
+	switch args[0] {
+	case "migrate":
+		runMigrate(args[1:])
+	case "failed":
+		runFailed(args[1:])
+	case "requeue":
+		runRequeue(args[1:])
+	case "replay":
+		runReplay(args[1:])
+	case "history":
+		runHistory(args[1:])
+	case "help", "-h", "--help":
+		fmt.Print(usage)
+	default:
+		fmt.Fprint(os.Stderr, usage)
+		os.Exit(2)
+	}
+}
+
+func runMigrate(args []string) {
+	if len(args) == 0 {
+		fmt.Fprint(os.Stderr, usage)
+		os.Exit(2)
+	}
+	loadEnv()
+	db.Connect()
+
+	switch args[0] {
+	case "status":
+		statuses, err := db.ListMigrations()
+		if err != nil {
+			l.Fatal("Failed to list migrations ", err)
+		}
+		w := tabwriter.NewWriter(os.Stdout, 0, 4, 2, ' ', 0)
+		fmt.Fprintln(w, "VERSION\tNAME\tAPPLIED AT")
+		for _, s := range statuses {
+			appliedAt := "pending"
+			if s.AppliedAt != nil {
+				appliedAt = s.AppliedAt.Format("2006-01-02 15:04:05 MST")
+			}
+			fmt.Fprintf(w, "%d\t%s\t%s\n", s.Version, s.Name, appliedAt)
+		}
+		w.Flush()
+	case "up":
+		count, err := db.Migrate()
+		if err != nil {
+			l.Fatal("Failed to migrate ", err)
+		}
+		fmt.Printf("Applied %d migrations\n", count)
+	case "down":
+		steps := 1
+		if len(args) > 1 {
+			var err error
+			if steps, err = strconv.Atoi(args[1]); err != nil || steps < 1 {
+				l.Fatal("Invalid number of steps ", args[1])
+			}
+		}
+		count, err := db.Rollback(steps)
+		if err != nil {
+			l.Fatal("Failed to rollback ", err)
+		}
+		fmt.Printf("Rolled back %d migrations\n", count)
+	default:
+		fmt.Fprint(os.Stderr, usage)
+		os.Exit(2)
+	}
+}
+
+func runFailed(args []string) {
+	dead := len(args) > 0 && args[0] == "--dead"
+	loadEnv()
+	db.Connect()
+
+	failures, err := db.ListFailures(context.Background(), dead)
+	if err != nil {
+		l.Fatal("Failed to list failed events ", err)
+	}
+	w := tabwriter.NewWriter(os.Stdout, 0, 4, 2, ' ', 0)
+	fmt.Fprintln(w, "ID\tORG\tTYPE\tBLOCK\tATTEMPTS\tNEXT ATTEMPT\tERROR")
+	for _, f := range failures {
+		next := "-"
+		if f.Dead {
+			next = "dead"
+		} else if f.NextAttemptAt != nil {
+			next = f.NextAttemptAt.Format("2006-01-02 15:04:05 MST")
+		}
+		fmt.Fprintf(w, "%d\t%s\t%s\t%d\t%d\t%s\t%s\n",
+			f.ID, f.Event.Org, f.Event.Type.String(), f.Event.BlockNumber, f.Attempts, next, f.Error)
+	}
+	w.Flush()
+}
+
+func runRequeue(args []string) {
+	if len(args) != 1 {
+		fmt.Fprint(os.Stderr, usage)
+		os.Exit(2)
+	}
+	id, err := strconv.ParseInt(args[0], 10, 64)
+	if err != nil {
+		l.Fatal("Invalid id ", args[0])
+	}
+	loadEnv()
+	db.Connect()
+
+	if err = db.Requeue(context.Background(), id); err != nil {
+		l.Fatal("Failed to requeue ", err)
+	}
+	fmt.Printf("Requeued failed event %d\n", id)
+}
+
+func runHistory(args []string) {
+	if len(args) != 1 {
+		fmt.Fprint(os.Stderr, usage)
+		os.Exit(2)
+	}
+	org := strings.ToLower(args[0])
+	loadEnv()
+	db.Connect()
+	ctx := context.Background()
+
+	history, err := db.ListHistory(ctx, org)
+	if err != nil {
+		l.Fatal("Failed to list history ", err)
+	}
+	dep, err := db.GetDeployment(ctx, org)
+	if err != nil && err != sql.ErrNoRows {
+		l.Fatal("Failed to get deployment ", err)
+	}
+	w := tabwriter.NewWriter(os.Stdout, 0, 4, 2, ' ', 0)
+	fmt.Fprintln(w, "START BLOCK\tEND BLOCK\tPROVIDER\tSERVER\tIP\tSTATUS\tREASON")
+	for _, h := range history {
+		fmt.Fprintf(w, "%d\t%d\t%s\t%s\t%s\t%s\t%s\n",
+			h.StartBlock, h.EndBlock, h.Provider, h.ServerID, h.IP, db.TerminatedStatus, h.Reason)
+	}
+	// a terminated deployment is already the last lifetime of its history
+	if err == nil && dep.Status != db.TerminatedStatus {
+		fmt.Fprintf(w, "%d\t%d\t%s\t%s\t%s\t%s\t%s\n",
+			dep.StartBlock, dep.Expiry, dep.Provider, dep.ServerID, dep.IP, dep.Status, "")
+	}
+	w.Flush()
+
+	transitions, err := db.ListTransitions(ctx, org)
+	if err != nil {
+		l.Fatal("Failed to list transitions ", err)
+	}
+	fmt.Println()
+	w = tabwriter.NewWriter(os.Stdout, 0, 4, 2, ' ', 0)
+	fmt.Fprintln(w, "AT\tFROM\tTO\tREASON")
+	for _, t := range transitions {
+		from := t.From
+		if from == "" {
+			from = "-"
+		}
+		fmt.Fprintf(w, "%s\t%s\t%s\t%s\n", t.CreatedAt.Format("2006-01-02 15:04:05 MST"), from, t.To, t.Reason)
+	}
+	w.Flush()
+}
@@ -69,6 +69,19 @@ abstract contract RadicleCloudBase {
     /// @param expiry when this deployment was stopped
     event DeploymentStopped(address org, uint64 expiry);
 
+    /// @notice Emitted when the rate new top ups are charged changes.
+    /// @param rate the new rate per block
+    event RateChanged(uint64 rate);
+
+    /// @notice Emitted when the owner of this contract changes.
+    /// @param owner the new owner
+    event OwnerChanged(address owner);
+
+    /// @notice Emitted when a deployment gets a new owner.
+    /// @param org the org owning the deployment
+    /// @param owner the new owner of the deployment
+    event DeploymentOwnerChanged(address org, address owner);
+
     /// @notice Modifier to check if caller is owner.
     modifier isOwner() {
         require(msg.sender == owner, "Caller is not owner");
@@ -89,18 +102,22 @@ abstract contract RadicleCloudBase {
         owner = _owner;
         ratePerBlock = price;
         lastProcessed = uint64(block.number);
+        emit OwnerChanged(_owner);
+        emit RateChanged(price);
     }
 
     /// @notice Change owner of contract.
     /// @param newOwner address of new owner
     function changeOwner(address newOwner) public isOwner {
         owner = newOwner;
+        emit OwnerChanged(newOwner);
     }
 
     /// @notice Change ratePerBlock for orders after this block.
     /// @param newRate the new rate per block
     function changeRate(uint64 newRate) public isOwner {
         ratePerBlock = newRate;
+        emit RateChanged(newRate);
     }
 
     /// @notice Return current package price.
@@ -155,6 +172,7 @@ abstract contract RadicleCloudBase {
             dep[org].expiry = uint64(block.number + packages * duration);
             dep[org].start = uint64(block.number);
             dep[org].owner = _owner;
+            emit DeploymentOwnerChanged(org, _owner);
 
             buys.add(uint64(block.number));
             expires.add((uint64(block.number + duration)));
@@ -0,0 +1,113 @@
+// SPDX-License-Identifier: Apache-2.0
+
+package db
+
+import (
+	"context"
+	"fmt"
+	"radicle-cloud/eth"
+)
+
+// Contract is the current rate and owner of a source's contract
+type Contract struct {
+	Source string
+	Rate   uint64
+	Owner  string
+}
+
+// RecordChange upserts a rate, owner, or deployment owner change and
+// overwrites its 'removed' column, along with its cursor if it carries one
+func RecordChange(ctx context.Context, e eth.Event) error {
+	return withCursor(ctx, e, func(ex execer) error {
+		return recordChange(ctx, ex, e)
+	})
+}
+
+func recordChange(ctx context.Context, ex execer, e eth.Event) error {
+	var err error
+	switch e.Type {
+	case eth.RateChangedEvent:
+		statement := `
+			INSERT INTO
+			rate_changes (source, blockAndTx, logIndex, emittedAt, rate, removed)
+			VALUES ($1, $2, $3, $4, $5, $6)
+			ON CONFLICT (blockAndTx, logIndex) DO
+			UPDATE SET removed = $6
+		`
+		_, err = ex.ExecContext(ctx, statement, e.Source, e.BlockAndTx, e.LogIndex, e.BlockNumber, e.Rate, e.Removed)
+	case eth.OwnerChangedEvent:
+		statement := `
+			INSERT INTO
+			owner_changes (source, blockAndTx, logIndex, emittedAt, owner, removed)
+			VALUES ($1, $2, $3, $4, $5, $6)
+			ON CONFLICT (blockAndTx, logIndex) DO
+			UPDATE SET removed = $6
+		`
+		_, err = ex.ExecContext(ctx, statement, e.Source, e.BlockAndTx, e.LogIndex, e.BlockNumber, e.Owner, e.Removed)
+	case eth.DeploymentOwnerChangedEvent:
+		statement := `
+			INSERT INTO
+			deployment_owner_changes (source, blockAndTx, logIndex, emittedAt, org, owner, removed)
+			VALUES ($1, $2, $3, $4, $5, $6, $7)
+			ON CONFLICT (blockAndTx, logIndex) DO
+			UPDATE SET removed = $7
+		`
+		_, err = ex.ExecContext(ctx, statement, e.Source, e.BlockAndTx, e.LogIndex, e.BlockNumber, e.Org, e.Owner, e.Removed)
+	default:
+		err = fmt.Errorf("%s is not a change", e.Type.String())
+	}
+	return err
+}
+
+// ListContracts returns the current rate and owner of every source
+func ListContracts(ctx context.Context) ([]Contract, error) {
+	contracts := []Contract{}
+	statement := `
+		SELECT COALESCE(r.source, o.source), COALESCE(r.rate, 0), COALESCE(o.owner, '') FROM (
+			SELECT DISTINCT ON (source) source, rate FROM rate_changes
+			WHERE removed = $1
+			ORDER BY source, emittedAt DESC, logIndex DESC
+		) r FULL OUTER JOIN (
+			SELECT DISTINCT ON (source) source, owner FROM owner_changes
+			WHERE removed = $1
+			ORDER BY source, emittedAt DESC, logIndex DESC
+		) o ON r.source = o.source
+		ORDER BY 1 ASC
+	`
+	rows, err := db.QueryContext(ctx, statement, false)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var c Contract
+	for rows.Next() {
+		if err = rows.Scan(&c.Source, &c.Rate, &c.Owner); err != nil {
+			return nil, err
+		}
+		contracts = append(contracts, c)
+	}
+	return contracts, rows.Err()
+}
+
+// DeploymentOwners returns the current owner of org's deployment by source
+func DeploymentOwners(ctx context.Context, org string) (map[string]string, error) {
+	owners := map[string]string{}
+	statement := `
+		SELECT DISTINCT ON (source) source, owner FROM deployment_owner_changes
+		WHERE org = $1 AND removed = $2
+		ORDER BY source, emittedAt DESC, logIndex DESC
+	`
+	rows, err := db.QueryContext(ctx, statement, org, false)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	for rows.Next() {
+		var source, owner string
+		if err = rows.Scan(&source, &owner); err != nil {
+			return nil, err
+		}
+		owners[source] = owner
+	}
+	return owners, rows.Err()
+}
@@ -0,0 +1,81 @@
+// SPDX-License-Identifier: Apache-2.0
+
+package db
+
+import (
+	"context"
+	"database/sql"
+	"radicle-cloud/eth"
+	"strings"
+
+	"github.com/ethereum/go-ethereum/common"
+)
+
+// execer is satisfied by both the DB and a transaction
+type execer interface {
+	ExecContext(ctx context.Context, query string, args ...interface{}) (sql.Result, error)
+}
+
+// splitSource splits chain:address into the key of chain_cursors
+func splitSource(source string) (string, string) {
+	parts := strings.SplitN(source, ":", 2)
+	if len(parts) != 2 {
+		return source, ""
+	}
+	return parts[0], parts[1]
+}
+
+// GetCursor returns the last block of source whose logs were all stored
+func GetCursor(ctx context.Context, source string) (eth.Cursor, error) {
+	var cursor eth.Cursor
+	var hash []byte
+	chain, contract := splitSource(source)
+	statement := `SELECT block, blockHash FROM chain_cursors WHERE chain = $1 AND contract = $2`
+	row := db.QueryRowContext(ctx, statement, chain, contract)
+	if err := row.Scan(&cursor.Block, &hash); err != nil {
+		return cursor, err
+	}
+	cursor.Hash = common.BytesToHash(hash)
+	return cursor, nil
+}
+
+// SaveCursor moves the cursor of source
+func SaveCursor(ctx context.Context, source string, cursor eth.Cursor) error {
+	return saveCursor(ctx, db, source, cursor)
+}
+
+func saveCursor(ctx context.Context, ex execer, source string, cursor eth.Cursor) error {
+	var hash []byte
+	if cursor.Hash != (common.Hash{}) {
+		hash = cursor.Hash.Bytes()
+	}
+	chain, contract := splitSource(source)
+	statement := `
+		INSERT INTO chain_cursors (chain, contract, block, blockHash, updatedAt)
+		VALUES ($1, $2, $3, $4, NOW())
+		ON CONFLICT (chain, contract) DO
+		UPDATE SET block = $3, blockHash = $4, updatedAt = NOW()
+	`
+	_, err := ex.ExecContext(ctx, statement, chain, contract, cursor.Block, hash)
+	return err
+}
+
+// withCursor runs store and saves the cursor of e in the same transaction, so
+// that the cursor never gets ahead of the stored events
+func withCursor(ctx context.Context, e eth.Event, store func(ex execer) error) error {
+	if e.Cursor == nil {
+		return store(db)
+	}
+	tx, err := db.BeginTx(ctx, nil)
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback() //nolint:errcheck
+	if err = store(tx); err != nil {
+		return err
+	}
+	if err = saveCursor(ctx, tx, e.Source, *e.Cursor); err != nil {
+		return err
+	}
+	return tx.Commit()
+}
@@ -0,0 +1,207 @@
+// SPDX-License-Identifier: Apache-2.0
+
+package db
+
+import (
+	"context"
+	"fmt"
+	"radicle-cloud/eth"
+	"time"
+)
+
+// FailedEvent is an event which failed processing and awaits a retry, or
+// manual requeue once it's dead
+type FailedEvent struct {
+	ID            int64
+	Event         eth.Event
+	Error         string
+	Attempts      int
+	Dead          bool
+	NextAttemptAt *time.Time
+	UpdatedAt     time.Time
+}
+
+// RecordFailure stores the error of event e and returns its number of attempts
+func RecordFailure(ctx context.Context, e eth.Event, reason string) (int, error) {
+	var attempts int
+	statement := `
+		INSERT INTO
+		failed_events (eventId, org, error, attempts)
+		SELECT id, org, $2, 1 FROM events
+		WHERE blockAndTx = $1 AND logIndex = $3
+		ON CONFLICT (eventId) DO
+		UPDATE SET error = $2, attempts = failed_events.attempts + 1, updatedAt = NOW()
+		RETURNING attempts
+	`
+	row := db.QueryRowContext(ctx, statement, e.BlockAndTx, reason, e.LogIndex)
+	return attempts, row.Scan(&attempts)
+}
+
+// ScheduleRetry sets when the failed event e is retried next
+func ScheduleRetry(ctx context.Context, e eth.Event, at time.Time) error {
+	statement := `
+		UPDATE failed_events
+		SET nextAttemptAt = $2
+		WHERE eventId = (SELECT id FROM events WHERE blockAndTx = $1 AND logIndex = $3)
+	`
+	_, err := db.ExecContext(ctx, statement, e.BlockAndTx, at, e.LogIndex)
+	return err
+}
+
+// MarkDead stops retrying the failed event e until it's requeued
+func MarkDead(ctx context.Context, e eth.Event) error {
+	statement := `
+		UPDATE failed_events
+		SET dead = $2, nextAttemptAt = NULL
+		WHERE eventId = (SELECT id FROM events WHERE blockAndTx = $1 AND logIndex = $3)
+	`
+	_, err := db.ExecContext(ctx, statement, e.BlockAndTx, true, e.LogIndex)
+	return err
+}
+
+// ResolveFailure removes event e from failed events after it succeeded
+func ResolveFailure(ctx context.Context, e eth.Event) error {
+	statement := `
+		DELETE FROM failed_events
+		WHERE eventId = (SELECT id FROM events WHERE blockAndTx = $1 AND logIndex = $2)
+	`
+	_, err := db.ExecContext(ctx, statement, e.BlockAndTx, e.LogIndex)
+	return err
+}
+
+// ResolveSupersededFailures removes failed events of orgs which have
+// processed a later event since, retrying those would roll back their state
+func ResolveSupersededFailures(ctx context.Context) (int64, error) {
+	statement := `
+		DELETE FROM failed_events f
+		USING events e, events later
+		WHERE e.id = f.eventId
+		AND later.org = e.org AND later.source = e.source AND later.emittedAt > e.emittedAt
+		AND later.processed = $1 AND later.removed = $2
+	`
+	res, err := db.ExecContext(ctx, statement, true, false)
+	if err != nil {
+		return 0, err
+	}
+	return res.RowsAffected()
+}
+
+// ClaimDueFailures returns failed events whose retry is due and unschedules
+// them so they're handed out once
+func ClaimDueFailures(ctx context.Context) ([]eth.Event, error) {
+	statement := `
+		UPDATE failed_events f
+		SET nextAttemptAt = NULL
+		FROM events e
+		WHERE e.id = f.eventId AND f.dead = $1 AND f.nextAttemptAt <= NOW()
+		RETURNING e.type, e.blockAndTx, e.logIndex, e.org, e.emittedAt, e.expiry, e.removed, e.source
+	`
+	rows, err := db.QueryContext(ctx, statement, false)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	events := []eth.Event{}
+	for rows.Next() {
+		e, err := scanEvent(rows.Scan)
+		if err != nil {
+			return nil, err
+		}
+		events = append(events, e)
+	}
+	return events, rows.Err()
+}
+
+// UnclaimFailures reschedules failures which were claimed but never finished,
+// e.g. because the operator stopped
+func UnclaimFailures(ctx context.Context) error {
+	statement := `
+		UPDATE failed_events
+		SET nextAttemptAt = NOW()
+		WHERE dead = $1 AND nextAttemptAt IS NULL
+	`
+	_, err := db.ExecContext(ctx, statement, false)
+	return err
+}
+
+// RequeueInterrupted schedules stored events of source which were never
+// processed for a retry, listening resumes after them so they'd be lost
+// otherwise
+func RequeueInterrupted(ctx context.Context, source string) (int64, error) {
+	statement := `
+		INSERT INTO
+		failed_events (eventId, org, error, attempts, nextAttemptAt)
+		SELECT id, org, $3, 0, NOW() FROM events
+		WHERE source = $1 AND processed = $2 AND removed = $2
+		ON CONFLICT (eventId) DO NOTHING
+	`
+	res, err := db.ExecContext(ctx, statement, source, false, "interrupted before processing")
+	if err != nil {
+		return 0, err
+	}
+	return res.RowsAffected()
+}
+
+// Requeue revives a failed event with id so that it's retried right away
+func Requeue(ctx context.Context, id int64) error {
+	statement := `
+		UPDATE failed_events
+		SET dead = $2, attempts = 0, nextAttemptAt = NOW(), updatedAt = NOW()
+		WHERE id = $1
+	`
+	res, err := db.ExecContext(ctx, statement, id, false)
+	if err != nil {
+		return err
+	}
+	if n, err := res.RowsAffected(); err != nil || n == 0 {
+		return fmt.Errorf("no failed event with id %d", id)
+	}
+	return nil
+}
+
+// ListFailures lists failed events, only dead ones if dead is true
+func ListFailures(ctx context.Context, dead bool) ([]FailedEvent, error) {
+	statement := `
+		SELECT f.id, f.error, f.attempts, f.dead, f.nextAttemptAt, f.updatedAt,
+			e.type, e.blockAndTx, e.logIndex, e.org, e.emittedAt, e.expiry, e.removed, e.source
+		FROM failed_events f
+		JOIN events e ON e.id = f.eventId
+		WHERE f.dead OR NOT $1
+		ORDER BY f.id ASC
+	`
+	rows, err := db.QueryContext(ctx, statement, dead)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	failures := []FailedEvent{}
+	for rows.Next() {
+		var f FailedEvent
+		f.Event, err = scanEvent(func(dest ...interface{}) error {
+			return rows.Scan(append([]interface{}{
+				&f.ID, &f.Error, &f.Attempts, &f.Dead, &f.NextAttemptAt, &f.UpdatedAt,
+			}, dest...)...)
+		})
+		if err != nil {
+			return nil, err
+		}
+		failures = append(failures, f)
+	}
+	return failures, rows.Err()
+}
+
+// scanEvent scans type, blockAndTx, logIndex, org, emittedAt, expiry, removed, source
+func scanEvent(scan func(dest ...interface{}) error) (eth.Event, error) {
+	var e eth.Event
+	var eventType string
+	err := scan(&eventType, &e.BlockAndTx, &e.LogIndex, &e.Org, &e.BlockNumber, &e.Expiry, &e.Removed, &e.Source)
+	if err != nil {
+		return e, err
+	}
+	t, ok := eth.ParseEventType(eventType)
+	if !ok {
+		return e, fmt.Errorf("unknown event type %s", eventType)
+	}
+	e.Type = t
+	return e, nil
+}
@@ -0,0 +1,67 @@
+// SPDX-License-Identifier: Apache-2.0
+
+package db
+
+import (
+	"context"
+	"time"
+)
+
+// HistoryRow is a row of the deployment_history table, a lifetime of a
+// deployment from its purchase until it was terminated
+type HistoryRow struct {
+	Org          string
+	Provider     string
+	IP           string
+	ServerID     string
+	StartBlock   uint64
+	EndBlock     uint64
+	Reason       string
+	StartedAt    time.Time
+	TerminatedAt time.Time
+}
+
+// ListHistory lists the terminated lifetimes of org from oldest to newest
+func ListHistory(ctx context.Context, org string) ([]HistoryRow, error) {
+	history := []HistoryRow{}
+	statement := `
+		SELECT org, provider, COALESCE(host(ip), ''), serverId, startBlock, endBlock, reason, startedAt, terminatedAt
+		FROM deployment_history
+		WHERE org = $1
+		ORDER BY id ASC
+	`
+	rows, err := db.QueryContext(ctx, statement, org)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var h HistoryRow
+	for rows.Next() {
+		err = rows.Scan(&h.Org, &h.Provider, &h.IP, &h.ServerID, &h.StartBlock, &h.EndBlock, &h.Reason, &h.StartedAt, &h.TerminatedAt)
+		if err != nil {
+			return nil, err
+		}
+		history = append(history, h)
+	}
+	if err = rows.Err(); err != nil {
+		return nil, err
+	}
+	return history, nil
+}
+
+// archive copies the current lifetime of org into deployment_history, its
+// reason is why the deployment was last moved to terminating
+func archive(ctx context.Context, ex execer, org string) error {
+	statement := `
+		INSERT INTO deployment_history (org, provider, ip, serverId, startBlock, endBlock, reason, startedAt)
+		SELECT org, provider, ip, serverId, startBlock, expiry, COALESCE((
+			SELECT reason FROM deployment_transitions t
+			WHERE t.org = d.org AND t.toStatus = $2
+			ORDER BY id DESC LIMIT 1
+		), ''), startedAt
+		FROM deployments d
+		WHERE org = $1
+	`
+	_, err := ex.ExecContext(ctx, statement, org, TerminatingStatus)
+	return err
+}
@@ -0,0 +1,111 @@
+// SPDX-License-Identifier: Apache-2.0
+
+package db
+
+import (
+	"context"
+	"database/sql"
+	"database/sql/driver"
+	"sync"
+	"time"
+)
+
+// leaderLock is the advisory lock key held by the leading operator
+const leaderLock = 0x72616c64 // "rald"
+
+// Leadership is held as long as the session owning the advisory lock lives
+type Leadership struct {
+	conn   *sql.Conn
+	lost   chan struct{}
+	once   sync.Once
+	resign sync.Once
+	stop   context.CancelFunc
+}
+
+// Campaign blocks until this operator holds the leader lock or ctx is done.
+// The lock lives in a dedicated session so it's released by Postgres as soon
+// as the session dies, which lets a standby take over within pollInterval.
+func Campaign(ctx context.Context, pollInterval time.Duration) (*Leadership, error) {
+	conn, err := db.Conn(ctx)
+	if err != nil {
+		return nil, err
+	}
+	// let the server notice a vanished leader quickly instead of after hours
+	for _, statement := range []string{
+		`SET tcp_keepalives_idle = 5`,
+		`SET tcp_keepalives_interval = 2`,
+		`SET tcp_keepalives_count = 3`,
+	} {
+		if _, err = conn.ExecContext(ctx, statement); err != nil {
+			l.Println("Failed to configure keepalives of leader session", err)
+		}
+	}
+
+	for {
+		var acquired bool
+		row := conn.QueryRowContext(ctx, `SELECT pg_try_advisory_lock($1)`, leaderLock)
+		if err = row.Scan(&acquired); err != nil {
+			conn.Close()
+			return nil, err
+		}
+		if acquired {
+			break
+		}
+		select {
+		case <-ctx.Done():
+			conn.Close()
+			return nil, ctx.Err()
+		case <-time.After(pollInterval):
+		}
+	}
+
+	keepCtx, stop := context.WithCancel(context.Background())
+	lead := &Leadership{conn: conn, lost: make(chan struct{}), stop: stop}
+	go lead.keepAlive(keepCtx, pollInterval)
+	return lead, nil
+}
+
+// keepAlive pings the lock session and gives up leadership once it's gone
+func (lead *Leadership) keepAlive(ctx context.Context, interval time.Duration) {
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-time.After(interval):
+		}
+		pingCtx, cancel := context.WithTimeout(ctx, interval)
+		_, err := lead.conn.ExecContext(pingCtx, `SELECT 1`)
+		cancel()
+		if err != nil && ctx.Err() == nil {
+			l.Println("Leader session died", err)
+			lead.markLost()
+			return
+		}
+	}
+}
+
+func (lead *Leadership) markLost() {
+	lead.once.Do(func() { close(lead.lost) })
+}
+
+// Lost is closed when leadership is lost
+func (lead *Leadership) Lost() <-chan struct{} {
+	return lead.lost
+}
+
+// Resign releases the leader lock so that a standby takes over right away
+func (lead *Leadership) Resign() {
+	lead.resign.Do(func() {
+		lead.stop()
+		ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+		defer cancel()
+		if _, err := lead.conn.ExecContext(ctx, `SELECT pg_advisory_unlock($1)`, leaderLock); err != nil {
+			l.Println("Failed to release leader lock", err)
+			// the session may still hold the lock, so it's closed instead of
+			// going back to the pool and the lock goes with it
+			lead.conn.Raw(func(interface{}) error { return driver.ErrBadConn }) //nolint:errcheck
+		}
+		lead.conn.Close()
+		lead.markLost()
+	})
+}
@@ -0,0 +1,219 @@
+// SPDX-License-Identifier: Apache-2.0
+
+package db
+
+import (
+	"database/sql"
+	"embed"
+	"fmt"
+	"io/fs"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+)
+
+//go:embed migrations/*.sql
+var migrationFiles embed.FS
+
+// migrationLock is the advisory lock key held while migrating
+const migrationLock = 0x7261636c // "racl"
+
+// Migration is a versioned schema change with its rollback
+type Migration struct {
+	Version uint
+	Name    string
+	Up      string
+	Down    string
+}
+
+// MigrationStatus is a known migration and when it was applied
+type MigrationStatus struct {
+	Migration
+	AppliedAt *time.Time
+}
+
+// loadMigrations reads migrations/<version>_<name>.{up,down}.sql by version
+func loadMigrations(fsys fs.FS) ([]Migration, error) {
+	paths, err := fs.Glob(fsys, "migrations/*.sql")
+	if err != nil {
+		return nil, err
+	}
+	byVersion := map[uint]*Migration{}
+	for _, path := range paths {
+		base := strings.TrimPrefix(path, "migrations/")
+		parts := strings.SplitN(strings.TrimSuffix(base, ".sql"), "_", 2)
+		if len(parts) != 2 {
+			return nil, fmt.Errorf("invalid migration file name %s", base)
+		}
+		version, err := strconv.ParseUint(parts[0], 10, 32)
+		if err != nil {
+			return nil, fmt.Errorf("invalid migration version in %s: %v", base, err)
+		}
+		var name, direction string
+		switch {
+		case strings.HasSuffix(parts[1], ".up"):
+			name, direction = strings.TrimSuffix(parts[1], ".up"), "up"
+		case strings.HasSuffix(parts[1], ".down"):
+			name, direction = strings.TrimSuffix(parts[1], ".down"), "down"
+		default:
+			return nil, fmt.Errorf("migration %s is neither up nor down", base)
+		}
+		content, err := fs.ReadFile(fsys, path)
+		if err != nil {
+			return nil, err
+		}
+
+		m, ok := byVersion[uint(version)]
+		if !ok {
+			m = &Migration{Version: uint(version), Name: name}
+			byVersion[uint(version)] = m
+		}
+		if m.Name != name {
+			return nil, fmt.Errorf("migration %d has two names %s and %s", version, m.Name, name)
+		}
+		if direction == "up" {
+			m.Up = string(content)
+		} else {
+			m.Down = string(content)
+		}
+	}
+
+	migrations := make([]Migration, 0, len(byVersion))
+	for _, m := range byVersion {
+		if m.Up == "" || m.Down == "" {
+			return nil, fmt.Errorf("migration %d_%s needs both up and down", m.Version, m.Name)
+		}
+		migrations = append(migrations, *m)
+	}
+	sort.Slice(migrations, func(i, j int) bool {
+		return migrations[i].Version < migrations[j].Version
+	})
+	for i, m := range migrations {
+		if m.Version != uint(i+1) {
+			return nil, fmt.Errorf("migration %d_%s is out of sequence", m.Version, m.Name)
+		}
+	}
+	return migrations, nil
+}
+
+func createMigrationsTable(tx *sql.Tx) error {
+	statement := `
+		CREATE TABLE IF NOT EXISTS schema_migrations (
+			version INTEGER PRIMARY KEY,
+			name TEXT NOT NULL,
+			appliedAt TIMESTAMPTZ NOT NULL DEFAULT NOW()
+		)
+	`
+	_, err := tx.Exec(statement)
+	return err
+}
+
+func appliedMigrations(tx *sql.Tx) (map[uint]time.Time, error) {
+	applied := map[uint]time.Time{}
+	rows, err := tx.Query(`SELECT version, appliedAt FROM schema_migrations`)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var version uint
+	var appliedAt time.Time
+	for rows.Next() {
+		if err = rows.Scan(&version, &appliedAt); err != nil {
+			return nil, err
+		}
+		applied[version] = appliedAt
+	}
+	return applied, rows.Err()
+}
+
+// withMigrationLock runs fn in a transaction holding the migration lock so
+// that concurrently starting operators don't migrate twice
+func withMigrationLock(fn func(tx *sql.Tx, migrations []Migration, applied map[uint]time.Time) error) error {
+	migrations, err := loadMigrations(migrationFiles)
+	if err != nil {
+		return err
+	}
+	tx, err := db.Begin()
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback() //nolint:errcheck
+	if _, err = tx.Exec(`SELECT pg_advisory_xact_lock($1)`, migrationLock); err != nil {
+		return err
+	}
+	if err = createMigrationsTable(tx); err != nil {
+		return err
+	}
+	applied, err := appliedMigrations(tx)
+	if err != nil {
+		return err
+	}
+	if err = fn(tx, migrations, applied); err != nil {
+		return err
+	}
+	return tx.Commit()
+}
+
+// Migrate applies all pending migrations and returns how many were applied
+func Migrate() (int, error) {
+	count := 0
+	err := withMigrationLock(func(tx *sql.Tx, migrations []Migration, applied map[uint]time.Time) error {
+		for _, m := range migrations {
+			if _, ok := applied[m.Version]; ok {
+				continue
+			}
+			l.Printf("Applying migration %d_%s\n", m.Version, m.Name)
+			if _, err := tx.Exec(m.Up); err != nil {
+				return fmt.Errorf("migration %d_%s: %v", m.Version, m.Name, err)
+			}
+			statement := `INSERT INTO schema_migrations (version, name) VALUES ($1, $2)`
+			if _, err := tx.Exec(statement, m.Version, m.Name); err != nil {
+				return err
+			}
+			count++
+		}
+		return nil
+	})
+	return count, err
+}
+
+// Rollback reverts the latest steps applied migrations
+func Rollback(steps int) (int, error) {
+	count := 0
+	err := withMigrationLock(func(tx *sql.Tx, migrations []Migration, applied map[uint]time.Time) error {
+		for i := len(migrations) - 1; i >= 0 && count < steps; i-- {
+			m := migrations[i]
+			if _, ok := applied[m.Version]; !ok {
+				continue
+			}
+			l.Printf("Rolling back migration %d_%s\n", m.Version, m.Name)
+			if _, err := tx.Exec(m.Down); err != nil {
+				return fmt.Errorf("rollback %d_%s: %v", m.Version, m.Name, err)
+			}
+			statement := `DELETE FROM schema_migrations WHERE version = $1`
+			if _, err := tx.Exec(statement, m.Version); err != nil {
+				return err
+			}
+			count++
+		}
+		return nil
+	})
+	return count, err
+}
+
+// ListMigrations returns every known migration and whether it's applied
+func ListMigrations() ([]MigrationStatus, error) {
+	statuses := []MigrationStatus{}
+	err := withMigrationLock(func(tx *sql.Tx, migrations []Migration, applied map[uint]time.Time) error {
+		for _, m := range migrations {
+			s := MigrationStatus{Migration: m}
+			if appliedAt, ok := applied[m.Version]; ok {
+				s.AppliedAt = &appliedAt
+			}
+			statuses = append(statuses, s)
+		}
+		return nil
+	})
+	return statuses, err
+}
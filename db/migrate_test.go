@@ -0,0 +1,37 @@
+// SPDX-License-Identifier: Apache-2.0
+
+package db
+
+import (
+	"testing"
+	"testing/fstest"
+)
+
+func TestLoadMigrations(t *testing.T) {
+	migrations, err := loadMigrations(migrationFiles)
+	if err != nil {
+		t.Fatal("Embedded migrations are invalid", err)
+	}
+	if len(migrations) == 0 || migrations[0].Name != "init" {
+		t.Errorf("Expected: first migration to be init, Actual: %+v", migrations)
+	}
+
+	missingDown := fstest.MapFS{
+		"migrations/0001_init.up.sql":   {Data: []byte("SELECT 1;")},
+		"migrations/0001_init.down.sql": {Data: []byte("SELECT 1;")},
+		"migrations/0002_next.up.sql":   {Data: []byte("SELECT 2;")},
+	}
+	if _, err := loadMigrations(missingDown); err == nil {
+		t.Error("Expected: error for migration without down")
+	}
+
+	gap := fstest.MapFS{
+		"migrations/0001_init.up.sql":   {Data: []byte("SELECT 1;")},
+		"migrations/0001_init.down.sql": {Data: []byte("SELECT 1;")},
+		"migrations/0003_next.up.sql":   {Data: []byte("SELECT 3;")},
+		"migrations/0003_next.down.sql": {Data: []byte("SELECT 3;")},
+	}
+	if _, err := loadMigrations(gap); err == nil {
+		t.Error("Expected: error for out of sequence migration")
+	}
+}
@@ -0,0 +1,7 @@
+-- SPDX-License-Identifier: Apache-2.0
+
+DROP TABLE IF EXISTS events;
+DROP TYPE IF EXISTS EVENT_TYPE;
+
+DROP TABLE IF EXISTS deployments;
+DROP TYPE IF EXISTS DEPLOYMENT_STATUS;
@@ -1,8 +1,14 @@
 -- SPDX-License-Identifier: Apache-2.0
 
-CREATE TYPE DEPLOYMENT_STATUS AS ENUM (
-    'initial', 'allocated', 'setup-failed', 'running'
-);
+-- installs which ran the former db/setup.sql already have these objects
+
+DO $$ BEGIN
+    CREATE TYPE DEPLOYMENT_STATUS AS ENUM (
+        'initial', 'allocated', 'setup-failed', 'running'
+    );
+EXCEPTION
+    WHEN duplicate_object THEN NULL;
+END $$;
 
 CREATE TABLE IF NOT EXISTS deployments (
     id BIGSERIAL PRIMARY KEY,
@@ -13,13 +19,17 @@ CREATE TABLE IF NOT EXISTS deployments (
     status DEPLOYMENT_STATUS NOT NULL DEFAULT 'initial'
 );
 
-CREATE INDEX ON deployments(org);
+CREATE INDEX IF NOT EXISTS deployments_org_idx ON deployments(org);
 
 --
 
-CREATE TYPE EVENT_TYPE AS ENUM (
-    'NewTopUp', 'DeploymentStopped'
-);
+DO $$ BEGIN
+    CREATE TYPE EVENT_TYPE AS ENUM (
+        'NewTopUp', 'DeploymentStopped'
+    );
+EXCEPTION
+    WHEN duplicate_object THEN NULL;
+END $$;
 
 CREATE TABLE IF NOT EXISTS events (
     id BIGSERIAL PRIMARY KEY,
@@ -32,5 +42,5 @@ CREATE TABLE IF NOT EXISTS events (
     removed BOOLEAN DEFAULT FALSE
 );
 
-CREATE INDEX ON events(org);
-CREATE INDEX ON events(emittedAt);
+CREATE INDEX IF NOT EXISTS events_org_idx ON events(org);
+CREATE INDEX IF NOT EXISTS events_emittedat_idx ON events(emittedAt);
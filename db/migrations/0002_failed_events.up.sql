@@ -0,0 +1,16 @@
+-- SPDX-License-Identifier: Apache-2.0
+
+CREATE TABLE IF NOT EXISTS failed_events (
+    id BIGSERIAL PRIMARY KEY,
+    eventId BIGINT NOT NULL UNIQUE REFERENCES events(id) ON DELETE CASCADE,
+    org VARCHAR(42) NOT NULL,
+    error TEXT NOT NULL,
+    attempts INTEGER NOT NULL DEFAULT 0,
+    dead BOOLEAN NOT NULL DEFAULT FALSE,
+    nextAttemptAt TIMESTAMPTZ,
+    createdAt TIMESTAMPTZ NOT NULL DEFAULT NOW(),
+    updatedAt TIMESTAMPTZ NOT NULL DEFAULT NOW()
+);
+
+CREATE INDEX IF NOT EXISTS failed_events_org_idx ON failed_events(org);
+CREATE INDEX IF NOT EXISTS failed_events_nextattemptat_idx ON failed_events(nextAttemptAt);
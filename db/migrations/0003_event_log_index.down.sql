@@ -0,0 +1,6 @@
+-- SPDX-License-Identifier: Apache-2.0
+
+ALTER TABLE events DROP CONSTRAINT IF EXISTS events_blockandtx_logindex_key;
+DELETE FROM events WHERE logIndex != 0;
+ALTER TABLE events DROP COLUMN IF EXISTS logIndex;
+ALTER TABLE events ADD CONSTRAINT events_blockandtx_key UNIQUE (blockAndTx);
@@ -0,0 +1,10 @@
+-- SPDX-License-Identifier: Apache-2.0
+
+-- several logs of one transaction, e.g. a multicall topping up many orgs,
+-- are told apart by their log index. Existing rows can't be backfilled and
+-- default to 0, re-reading them from chain is harmless since they carry the
+-- same expiry.
+
+ALTER TABLE events ADD COLUMN IF NOT EXISTS logIndex INTEGER NOT NULL DEFAULT 0;
+ALTER TABLE events DROP CONSTRAINT IF EXISTS events_blockandtx_key;
+ALTER TABLE events ADD CONSTRAINT events_blockandtx_logindex_key UNIQUE (blockAndTx, logIndex);
@@ -0,0 +1,4 @@
+-- SPDX-License-Identifier: Apache-2.0
+
+DROP INDEX IF EXISTS events_source_emittedat_idx;
+ALTER TABLE events DROP COLUMN IF EXISTS source;
@@ -0,0 +1,9 @@
+-- SPDX-License-Identifier: Apache-2.0
+
+-- events are tagged with the chain:address of the contract they came from,
+-- events stored before this have an empty source and are adopted by the
+-- first configured source on startup
+
+ALTER TABLE events ADD COLUMN IF NOT EXISTS source VARCHAR(80) NOT NULL DEFAULT '';
+
+CREATE INDEX IF NOT EXISTS events_source_emittedat_idx ON events(source, emittedAt);
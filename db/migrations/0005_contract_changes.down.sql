@@ -0,0 +1,5 @@
+-- SPDX-License-Identifier: Apache-2.0
+
+DROP TABLE IF EXISTS deployment_owner_changes;
+DROP TABLE IF EXISTS owner_changes;
+DROP TABLE IF EXISTS rate_changes;
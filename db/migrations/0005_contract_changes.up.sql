@@ -0,0 +1,40 @@
+-- SPDX-License-Identifier: Apache-2.0
+
+-- changes of the rate, contract owner and deployment owners by source, the
+-- latest row of a source which wasn't removed by a reorg is the current state
+
+CREATE TABLE IF NOT EXISTS rate_changes (
+    id BIGSERIAL PRIMARY KEY,
+    source VARCHAR(80) NOT NULL,
+    blockAndTx BYTEA NOT NULL,
+    logIndex INTEGER NOT NULL,
+    emittedAt NUMERIC NOT NULL,
+    rate NUMERIC NOT NULL,
+    removed BOOLEAN NOT NULL DEFAULT FALSE,
+    UNIQUE (blockAndTx, logIndex)
+);
+
+CREATE TABLE IF NOT EXISTS owner_changes (
+    id BIGSERIAL PRIMARY KEY,
+    source VARCHAR(80) NOT NULL,
+    blockAndTx BYTEA NOT NULL,
+    logIndex INTEGER NOT NULL,
+    emittedAt NUMERIC NOT NULL,
+    owner VARCHAR(42) NOT NULL,
+    removed BOOLEAN NOT NULL DEFAULT FALSE,
+    UNIQUE (blockAndTx, logIndex)
+);
+
+CREATE TABLE IF NOT EXISTS deployment_owner_changes (
+    id BIGSERIAL PRIMARY KEY,
+    source VARCHAR(80) NOT NULL,
+    blockAndTx BYTEA NOT NULL,
+    logIndex INTEGER NOT NULL,
+    emittedAt NUMERIC NOT NULL,
+    org VARCHAR(42) NOT NULL,
+    owner VARCHAR(42) NOT NULL,
+    removed BOOLEAN NOT NULL DEFAULT FALSE,
+    UNIQUE (blockAndTx, logIndex)
+);
+
+CREATE INDEX IF NOT EXISTS deployment_owner_changes_org_idx ON deployment_owner_changes(org);
@@ -0,0 +1,10 @@
+-- SPDX-License-Identifier: Apache-2.0
+
+-- the block up to which all logs of a source were stored, so that blocks
+-- without events aren't fetched again on restart
+
+CREATE TABLE IF NOT EXISTS log_checkpoints (
+    source VARCHAR(80) PRIMARY KEY,
+    block NUMERIC NOT NULL,
+    updatedAt TIMESTAMPTZ NOT NULL DEFAULT NOW()
+);
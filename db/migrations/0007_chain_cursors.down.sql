@@ -0,0 +1,14 @@
+-- SPDX-License-Identifier: Apache-2.0
+
+CREATE TABLE IF NOT EXISTS log_checkpoints (
+    source VARCHAR(80) PRIMARY KEY,
+    block NUMERIC NOT NULL,
+    updatedAt TIMESTAMPTZ NOT NULL DEFAULT NOW()
+);
+
+INSERT INTO log_checkpoints (source, block, updatedAt)
+SELECT chain || ':' || contract, block, updatedAt
+FROM chain_cursors
+ON CONFLICT DO NOTHING;
+
+DROP TABLE IF EXISTS chain_cursors;
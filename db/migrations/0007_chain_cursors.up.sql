@@ -0,0 +1,20 @@
+-- SPDX-License-Identifier: Apache-2.0
+
+-- the last block of a contract whose logs were all stored, it's written in
+-- the same transaction as the events up to it and replaces log_checkpoints
+
+CREATE TABLE IF NOT EXISTS chain_cursors (
+    chain VARCHAR(37) NOT NULL,
+    contract VARCHAR(42) NOT NULL,
+    block NUMERIC NOT NULL,
+    blockHash BYTEA,
+    updatedAt TIMESTAMPTZ NOT NULL DEFAULT NOW(),
+    PRIMARY KEY (chain, contract)
+);
+
+INSERT INTO chain_cursors (chain, contract, block, updatedAt)
+SELECT split_part(source, ':', 1), split_part(source, ':', 2), block, updatedAt
+FROM log_checkpoints
+ON CONFLICT DO NOTHING;
+
+DROP TABLE IF EXISTS log_checkpoints;
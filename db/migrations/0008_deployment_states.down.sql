@@ -0,0 +1,22 @@
+-- SPDX-License-Identifier: Apache-2.0
+
+DROP TABLE IF EXISTS deployment_transitions;
+
+-- terminated deployments used to be deleted, the others fall back to the
+-- closest old status and are picked up again
+DELETE FROM deployments WHERE status = 'terminated';
+
+CREATE TYPE DEPLOYMENT_STATE AS ENUM ('initial', 'allocated', 'setup-failed', 'running');
+
+ALTER TABLE deployments ALTER COLUMN status DROP DEFAULT;
+ALTER TABLE deployments ALTER COLUMN status TYPE DEPLOYMENT_STATE USING (
+    CASE status::text
+        WHEN 'dns-created' THEN 'allocated'
+        WHEN 'suspended' THEN 'running'
+        WHEN 'terminating' THEN 'running'
+        ELSE status::text
+    END
+)::DEPLOYMENT_STATE;
+ALTER TABLE deployments ALTER COLUMN status SET DEFAULT 'initial';
+DROP TYPE DEPLOYMENT_STATUS;
+ALTER TYPE DEPLOYMENT_STATE RENAME TO DEPLOYMENT_STATUS;
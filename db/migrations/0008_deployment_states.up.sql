@@ -0,0 +1,33 @@
+-- SPDX-License-Identifier: Apache-2.0
+
+-- ALTER TYPE ... ADD VALUE can't be used within the migration's transaction,
+-- so the status type is swapped for one with the new states
+
+CREATE TYPE DEPLOYMENT_STATE AS ENUM (
+    'initial',
+    'allocated',
+    'dns-created',
+    'setup-failed',
+    'running',
+    'suspended',
+    'terminating',
+    'terminated'
+);
+
+ALTER TABLE deployments ALTER COLUMN status DROP DEFAULT;
+ALTER TABLE deployments ALTER COLUMN status TYPE DEPLOYMENT_STATE USING status::text::DEPLOYMENT_STATE;
+ALTER TABLE deployments ALTER COLUMN status SET DEFAULT 'initial';
+DROP TYPE DEPLOYMENT_STATUS;
+ALTER TYPE DEPLOYMENT_STATE RENAME TO DEPLOYMENT_STATUS;
+
+-- every status change of a deployment, fromStatus is empty when it was created
+CREATE TABLE IF NOT EXISTS deployment_transitions (
+    id BIGSERIAL PRIMARY KEY,
+    org VARCHAR(42) NOT NULL,
+    fromStatus VARCHAR(20) NOT NULL,
+    toStatus VARCHAR(20) NOT NULL,
+    reason TEXT NOT NULL,
+    createdAt TIMESTAMPTZ NOT NULL DEFAULT NOW()
+);
+
+CREATE INDEX IF NOT EXISTS deployment_transitions_org ON deployment_transitions (org, id);
@@ -0,0 +1,7 @@
+-- SPDX-License-Identifier: Apache-2.0
+
+DROP TABLE IF EXISTS deployment_history;
+
+ALTER TABLE deployments DROP COLUMN IF EXISTS startedAt;
+ALTER TABLE deployments DROP COLUMN IF EXISTS startBlock;
+ALTER TABLE deployments DROP COLUMN IF EXISTS serverId;
@@ -0,0 +1,36 @@
+-- SPDX-License-Identifier: Apache-2.0
+
+-- the server and start of the current lifetime of a deployment
+ALTER TABLE deployments ADD COLUMN IF NOT EXISTS serverId VARCHAR(64) NOT NULL DEFAULT '';
+ALTER TABLE deployments ADD COLUMN IF NOT EXISTS startBlock NUMERIC NOT NULL DEFAULT 0;
+ALTER TABLE deployments ADD COLUMN IF NOT EXISTS startedAt TIMESTAMPTZ NOT NULL DEFAULT NOW();
+
+UPDATE deployments d
+SET startBlock = COALESCE((SELECT MIN(emittedAt) FROM events e WHERE e.org = d.org), 0);
+
+-- every lifetime of a deployment which was terminated, an org has one per
+-- purchase after the previous one expired
+CREATE TABLE IF NOT EXISTS deployment_history (
+    id BIGSERIAL PRIMARY KEY,
+    org VARCHAR(42) NOT NULL,
+    provider VARCHAR(20) NOT NULL,
+    ip INET,
+    serverId VARCHAR(64) NOT NULL,
+    startBlock NUMERIC NOT NULL,
+    endBlock NUMERIC NOT NULL,
+    reason TEXT NOT NULL,
+    startedAt TIMESTAMPTZ NOT NULL,
+    terminatedAt TIMESTAMPTZ NOT NULL DEFAULT NOW()
+);
+
+CREATE INDEX IF NOT EXISTS deployment_history_org ON deployment_history (org, id);
+
+-- deployments terminated before history was kept
+INSERT INTO deployment_history (org, provider, ip, serverId, startBlock, endBlock, reason, startedAt)
+SELECT org, provider, ip, serverId, startBlock, expiry, COALESCE((
+    SELECT reason FROM deployment_transitions t
+    WHERE t.org = d.org AND t.toStatus = 'terminating'
+    ORDER BY id DESC LIMIT 1
+), ''), startedAt
+FROM deployments d
+WHERE status = 'terminated';
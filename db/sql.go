@@ -3,6 +3,7 @@
 package db
 
 import (
+	"context"
 	"database/sql"
 	"log"
 	"os"
@@ -15,19 +16,22 @@ import (
 var db *sql.DB
 var l *log.Logger
 
-const (
-	InitialStatus     string = "initial"
-	AllocatedStatus   string = "allocated"
-	SetupFailedStatus string = "setup-failed"
-	RunningStatus     string = "running"
-)
-
 func init() {
 	l = log.New(os.Stderr, "[DB]	", log.Ldate|log.Ltime|log.Lshortfile)
 }
 
-// Setup initializes the postgres client
+// Setup initializes the postgres client and applies pending migrations
 func Setup() {
+	Connect()
+	count, err := Migrate()
+	if err != nil {
+		l.Fatal("Failed to migrate DB ", err)
+	}
+	l.Printf("Applied %d migrations\n", count)
+}
+
+// Connect initializes the postgres client without migrating
+func Connect() {
 	tries := 5
 	var err error
 	conn := os.Getenv("POSTGRES")
@@ -51,76 +55,137 @@ func Setup() {
 	}
 }
 
-// UpsertDep upserts record for org and returns provider, ip, and status
-func UpsertDep(e eth.Event) (string, string, string, error) {
-	provider := ""
-	ip := ""
-	status := InitialStatus
+// UpsertDep upserts record for org with its merged expiry and returns the
+// deployment as it was before along with the new expiry. A terminated
+// deployment which is paid for again starts a new lifetime from initial.
+func UpsertDep(ctx context.Context, e eth.Event) (Dep, error) {
+	d := Dep{Org: e.Org}
+	var err error
+	if d.Expiry, err = MergedExpiry(ctx, e.Org); err != nil {
+		return d, err
+	}
+	tx, err := db.BeginTx(ctx, nil)
+	if err != nil {
+		return d, err
+	}
+	defer tx.Rollback() //nolint:errcheck
 	statement := `
-		SELECT provider, ip, status FROM deployments
+		SELECT provider, COALESCE(host(ip), ''), status FROM deployments
 		WHERE org = $1
+		FOR UPDATE
 	`
-	row := db.QueryRow(statement, e.Org)
-	err := row.Scan(&provider, &ip, &status)
-	if err != nil && err != sql.ErrNoRows {
-		return provider, ip, status, err
+	row := tx.QueryRowContext(ctx, statement, e.Org)
+	err = row.Scan(&d.Provider, &d.IP, &d.Status)
+	switch {
+	case err == sql.ErrNoRows:
+		statement = `
+			INSERT INTO deployments (org, expiry, startBlock)
+			VALUES ($1, $2, $3)
+		`
+		if _, err = tx.ExecContext(ctx, statement, e.Org, d.Expiry, e.BlockNumber); err != nil {
+			return d, err
+		}
+		d.Status = InitialStatus
+		err = recordTransition(ctx, tx, e.Org, "", InitialStatus, "purchased")
+	case err != nil:
+		return d, err
+	case d.Status == TerminatedStatus:
+		statement = `
+			UPDATE deployments
+			SET expiry = $2, provider = '', ip = NULL, serverId = '', startBlock = $3, startedAt = NOW()
+			WHERE org = $1
+		`
+		if _, err = tx.ExecContext(ctx, statement, e.Org, d.Expiry, e.BlockNumber); err != nil {
+			return d, err
+		}
+		d.Status, d.Provider, d.IP = InitialStatus, "", ""
+		err = transition(ctx, tx, e.Org, TerminatedStatus, InitialStatus, "purchased again")
+	case d.Status == TerminatingStatus:
+		// the expiry it ended at is archived, a new one starts once it's over
+	default:
+		statement = `
+			UPDATE deployments
+			SET expiry = $2
+			WHERE org = $1
+		`
+		_, err = tx.ExecContext(ctx, statement, e.Org, d.Expiry)
 	}
-	// upsert deployment
-	statement = `
-    	INSERT INTO
-    	deployments (org, expiry)
-    	VALUES 		($1,  	  $2)
-    	ON CONFLICT (org) DO
-      	UPDATE SET expiry = $2;
-  	`
-	_, err = db.Exec(statement, e.Org, e.Expiry)
-	return provider, ip, status, err
+	if err != nil {
+		return d, err
+	}
+	return d, tx.Commit()
 }
 
-// UpdateOrgServer sets the ip of reserved server for this org
-func UpdateOrgServer(org string, ip string, provider string) error {
+// MergedExpiry returns the furthest expiry of org among the latest events of
+// every source, so that paying through any contract extends the deployment.
+// Only sources counting expiries in L1 blocks are merged, events of others
+// which may be left from an earlier configuration are ignored.
+func MergedExpiry(ctx context.Context, org string) (uint64, error) {
 	statement := `
-		UPDATE deployments
-		SET ip = $2, provider = $3, status = $4
-		WHERE org = $1
+		SELECT DISTINCT ON (source) source, expiry FROM events
+		WHERE org = $1 AND removed = $2
+		ORDER BY source, emittedAt DESC, logIndex DESC
 	`
-	_, err := db.Exec(statement, org, ip, provider, "allocated")
-	return err
+	rows, err := db.QueryContext(ctx, statement, org, false)
+	if err != nil {
+		return 0, err
+	}
+	defer rows.Close()
+	var merged uint64
+	for rows.Next() {
+		var source string
+		var expiry uint64
+		if err = rows.Scan(&source, &expiry); err != nil {
+			return 0, err
+		}
+		chain, _ := splitSource(source)
+		if eth.OnL1Clock(chain) && expiry > merged {
+			merged = expiry
+		}
+	}
+	return merged, rows.Err()
 }
 
-// SetStatus changes the status of the org in DB
-func SetStatus(org string, status string) error {
+// AdoptEvents assigns events stored before sources were tracked to source
+func AdoptEvents(ctx context.Context, source string) (int64, error) {
 	statement := `
-		UPDATE deployments
-		SET status = $2
-		WHERE org = $1
+		UPDATE events
+		SET source = $1
+		WHERE source = ''
 	`
-	_, err := db.Exec(statement, org, status)
-	return err
+	res, err := db.ExecContext(ctx, statement, source)
+	if err != nil {
+		return 0, err
+	}
+	return res.RowsAffected()
 }
 
 // Dep struct has Org's name, its Expiry (block number), and Provider
 type Dep struct {
-	Org      string
-	Expiry   uint64
-	Provider string
+	Org        string
+	Expiry     uint64
+	Provider   string
+	IP         string
+	Status     string
+	ServerID   string
+	StartBlock uint64
 }
 
 // ListDeployments lists all deployments with ascending expiry
-func ListDeployments() ([]Dep, error) {
+func ListDeployments(ctx context.Context) ([]Dep, error) {
 	deps := []Dep{}
 	statement := `
-		SELECT org, expiry, provider FROM deployments
+		SELECT org, expiry, provider, COALESCE(host(ip), ''), status, serverId, startBlock FROM deployments
 		ORDER BY expiry ASC
 	`
-	rows, err := db.Query(statement)
+	rows, err := db.QueryContext(ctx, statement)
 	if err != nil {
 		return nil, err
 	}
 	defer rows.Close()
 	var d Dep
 	for rows.Next() {
-		err = rows.Scan(&d.Org, &d.Expiry, &d.Provider)
+		err = rows.Scan(&d.Org, &d.Expiry, &d.Provider, &d.IP, &d.Status, &d.ServerID, &d.StartBlock)
 		if err != nil {
 			return nil, err
 		}
@@ -132,112 +197,98 @@ func ListDeployments() ([]Dep, error) {
 	return deps, nil
 }
 
-// DeleteOrg deletes deployment and events belonging to org
-func DeleteOrg(org string) error {
-	statement := `
-		DELETE FROM deployments
-		WHERE org = $1
-	`
-	_, err := db.Exec(statement, org)
-	if err != nil {
-		return err
-	}
-	statement = `
-		DELETE FROM events
-		WHERE org = $1
-	`
-	_, err = db.Exec(statement, org)
-	return err
-}
-
 // GetProvider returns the cloud provider for the org passed to it
-func GetProvider(org string) (string, error) {
+func GetProvider(ctx context.Context, org string) (string, error) {
 	var provider string
 	statement := `
 		SELECT provider FROM deployments
 		WHERE org = $1
 	`
-	row := db.QueryRow(statement, org)
+	row := db.QueryRowContext(ctx, statement, org)
 	return provider, row.Scan(&provider)
 }
 
-// GetSmallestUnprocessedEvent returns smallest unprocessed emittedAt
-func GetSmallestUnprocessedEvent() (uint64, error) {
+// GetSmallestUnprocessedEvent returns smallest unprocessed emittedAt of source
+func GetSmallestUnprocessedEvent(ctx context.Context, source string) (uint64, error) {
 	var emittedAt uint64
 	statement := `
 		SELECT emittedAt FROM events
-		WHERE processed != $1 OR removed = $1
+		WHERE source = $2 AND (processed != $1 OR removed = $1)
 		ORDER BY emittedAt ASC LIMIT 1
 	`
-	row := db.QueryRow(statement, true)
+	row := db.QueryRowContext(ctx, statement, true, source)
 	return emittedAt, row.Scan(&emittedAt)
 }
 
-// GetLargestProcessedEvent returns largest processed emittedAt
-func GetLargestProcessedEvent() (uint64, error) {
+// GetLargestProcessedEvent returns largest processed emittedAt of source
+func GetLargestProcessedEvent(ctx context.Context, source string) (uint64, error) {
 	var emittedAt uint64
 	statement := `
 		SELECT emittedAt FROM events
-		WHERE processed = $1
+		WHERE source = $2 AND processed = $1
 		ORDER BY emittedAt DESC LIMIT 1
 	`
-	row := db.QueryRow(statement, true)
+	row := db.QueryRowContext(ctx, statement, true, source)
 	return emittedAt, row.Scan(&emittedAt)
 }
 
-// GetLastProcessedBlock returns smallestUnprocessed or largestProcessed or currentBlock
-func GetLastProcessedBlock() (uint64, error) {
-	lastEmittedAt, err := GetSmallestUnprocessedEvent()
+// GetLastProcessedBlock returns smallestUnprocessed or largestProcessed of
+// source, it's only used for sources which have no cursor yet
+func GetLastProcessedBlock(ctx context.Context, source string) (uint64, error) {
+	lastEmittedAt, err := GetSmallestUnprocessedEvent(ctx, source)
 	if err != nil {
 		if err != sql.ErrNoRows {
-			l.Println("No rows when getting smallest emittedAt", err)
+			return 0, err
 		}
-		lastEmittedAt, err = GetLargestProcessedEvent()
+		lastEmittedAt, err = GetLargestProcessedEvent(ctx, source)
 		// for processed case, we want to start looking from t+1
 		return lastEmittedAt + 1, err
 	}
 	return lastEmittedAt, nil
 }
 
-// UpsertEvent upserts the event and overwrites 'removed' column
-func UpsertEvent(e eth.Event) error {
+// UpsertEvent upserts the event and overwrites 'removed' column, along with
+// its cursor if it carries one
+func UpsertEvent(ctx context.Context, e eth.Event) error {
 	// upsert the org
 	statement := `
     	INSERT INTO
-    	events (type,	blockAndTx,	org,	emittedAt,	expiry,	removed)
-    	VALUES ($1,		$2,			 $3,	$4,			$5,			 $6)
-    	ON CONFLICT (blockAndTx) DO
+    	events (type,	blockAndTx,	org,	emittedAt,	expiry,	removed,	logIndex,	source)
+    	VALUES ($1,		$2,			 $3,	$4,			$5,			 $6,		$7,			$8)
+    	ON CONFLICT (blockAndTx, logIndex) DO
       	UPDATE SET removed = $6;
   	`
-	_, err := db.Exec(statement, e.Type.String(), e.BlockAndTx, e.Org, e.BlockNumber, e.Expiry, e.Removed)
-	return err
+	return withCursor(ctx, e, func(ex execer) error {
+		_, err := ex.ExecContext(ctx, statement, e.Type.String(), e.BlockAndTx, e.Org, e.BlockNumber, e.Expiry, e.Removed, e.LogIndex, e.Source)
+		return err
+	})
 }
 
-// MarkEventProcessed sets processed to true for event of this blockAndTx
-func MarkEventProcessed(blockAndTx []byte) error {
+// MarkEventProcessed sets processed to true for event of this blockAndTx and logIndex
+func MarkEventProcessed(ctx context.Context, blockAndTx []byte, logIndex uint) error {
 	statement := `
 		UPDATE events
-		SET processed = $2
-		WHERE blockAndTx = $1
+		SET processed = $3
+		WHERE blockAndTx = $1 AND logIndex = $2
 	`
-	_, err := db.Exec(statement, blockAndTx, true)
+	_, err := db.ExecContext(ctx, statement, blockAndTx, logIndex, true)
 	return err
 }
 
-// ListOrgEvents lists all events which are not marked as removed
-func ListOrgEvents(org string) ([]eth.Event, error) {
+// ListOrgEvents lists all events of org from source which are not marked as removed
+func ListOrgEvents(ctx context.Context, org string, source string) ([]eth.Event, error) {
 	events := []eth.Event{}
 	statement := `
 		SELECT emittedAt, expiry FROM events
-		WHERE removed = $1
-		ORDER BY emittedAt DESC
+		WHERE org = $1 AND source = $3 AND removed = $2
+		ORDER BY emittedAt DESC, logIndex DESC
 	`
-	rows, err := db.Query(statement)
+	rows, err := db.QueryContext(ctx, statement, org, false, source)
 	if err != nil {
 		return nil, err
 	}
 	defer rows.Close()
-	var e eth.Event
+	e := eth.Event{Org: org, Source: source}
 	for rows.Next() {
 		err = rows.Scan(&e.BlockNumber, &e.Expiry)
 		if err != nil {
@@ -251,12 +302,53 @@ func ListOrgEvents(org string) ([]eth.Event, error) {
 	return events, nil
 }
 
-// DeleteOrgEventsBefore deletes all events that happened before emittedAt
-func DeleteOrgEventsBefore(org string, emittedAt uint64) error {
+// GetDeployment returns the deployment of org
+func GetDeployment(ctx context.Context, org string) (Dep, error) {
+	d := Dep{Org: org}
 	statement := `
-		DELETE FROM events
-		WHERE org = $1 AND emittedAt < $2
+		SELECT expiry, provider, COALESCE(host(ip), ''), status, serverId, startBlock FROM deployments
+		WHERE org = $1
 	`
-	_, err := db.Exec(statement, org, emittedAt)
-	return err
+	row := db.QueryRowContext(ctx, statement, org)
+	return d, row.Scan(&d.Expiry, &d.Provider, &d.IP, &d.Status, &d.ServerID, &d.StartBlock)
+}
+
+// EventRow is a row of the events table
+type EventRow struct {
+	Type       string
+	BlockAndTx []byte
+	LogIndex   uint
+	Org        string
+	EmittedAt  uint64
+	Expiry     uint64
+	Processed  bool
+	Removed    bool
+	Source     string
+}
+
+// ListEvents lists all events of org including removed ones by emittedAt
+func ListEvents(ctx context.Context, org string) ([]EventRow, error) {
+	events := []EventRow{}
+	statement := `
+		SELECT type, blockAndTx, logIndex, org, emittedAt, expiry, processed, removed, source FROM events
+		WHERE org = $1
+		ORDER BY source ASC, emittedAt ASC, logIndex ASC
+	`
+	rows, err := db.QueryContext(ctx, statement, org)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var e EventRow
+	for rows.Next() {
+		err = rows.Scan(&e.Type, &e.BlockAndTx, &e.LogIndex, &e.Org, &e.EmittedAt, &e.Expiry, &e.Processed, &e.Removed, &e.Source)
+		if err != nil {
+			return nil, err
+		}
+		events = append(events, e)
+	}
+	if err = rows.Err(); err != nil {
+		return nil, err
+	}
+	return events, nil
 }
@@ -0,0 +1,220 @@
+// SPDX-License-Identifier: Apache-2.0
+
+package db
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+)
+
+const (
+	InitialStatus     string = "initial"
+	AllocatedStatus   string = "allocated"
+	DNSCreatedStatus  string = "dns-created"
+	SetupFailedStatus string = "setup-failed"
+	RunningStatus     string = "running"
+	SuspendedStatus   string = "suspended"
+	TerminatingStatus string = "terminating"
+	TerminatedStatus  string = "terminated"
+)
+
+// transitions lists the statuses a deployment may move to from each status,
+// a deployment is created from the empty status
+var transitions = map[string][]string{
+	"":                {InitialStatus},
+	InitialStatus:     {AllocatedStatus, TerminatingStatus},
+	AllocatedStatus:   {DNSCreatedStatus, TerminatingStatus},
+	DNSCreatedStatus:  {RunningStatus, SetupFailedStatus, TerminatingStatus},
+	SetupFailedStatus: {RunningStatus, SetupFailedStatus, TerminatingStatus},
+	RunningStatus:     {SuspendedStatus, TerminatingStatus},
+	SuspendedStatus:   {RunningStatus, TerminatingStatus},
+	TerminatingStatus: {TerminatedStatus},
+	TerminatedStatus:  {InitialStatus},
+}
+
+// ErrInvalidTransition is returned for a transition the state machine forbids
+var ErrInvalidTransition = errors.New("invalid deployment transition")
+
+// ErrStaleStatus is returned when a deployment isn't in the status a
+// transition starts from anymore
+var ErrStaleStatus = errors.New("deployment status changed")
+
+// CanTransition reports whether a deployment may move from status from to to
+func CanTransition(from string, to string) bool {
+	for _, next := range transitions[from] {
+		if next == to {
+			return true
+		}
+	}
+	return false
+}
+
+// TransitionRow is a row of the deployment_transitions table
+type TransitionRow struct {
+	From      string
+	To        string
+	Reason    string
+	CreatedAt time.Time
+}
+
+// Transition moves the deployment of org from status from to to and records
+// why, it fails if the deployment was moved away from from in the meantime
+func Transition(ctx context.Context, org string, from string, to string, reason string) error {
+	return inTx(ctx, func(ex execer) error {
+		return transition(ctx, ex, org, from, to, reason)
+	})
+}
+
+// UpdateOrgServer sets the reserved server of org and moves it to allocated
+func UpdateOrgServer(ctx context.Context, org string, ip string, provider string, serverID string) error {
+	return inTx(ctx, func(ex execer) error {
+		statement := `
+			UPDATE deployments
+			SET ip = $2, provider = $3, serverId = $4
+			WHERE org = $1
+		`
+		if _, err := ex.ExecContext(ctx, statement, org, ip, provider, serverID); err != nil {
+			return err
+		}
+		reason := fmt.Sprintf("reserved server %s at %s", ip, provider)
+		return transition(ctx, ex, org, InitialStatus, AllocatedStatus, reason)
+	})
+}
+
+// BeginTermination moves the deployment of org to terminating because it
+// expired at block expiry and returns it. It reports false and leaves the
+// deployment alone if it's terminated already or was renewed past expiry in
+// the meantime, a terminating one is returned to finish its termination.
+func BeginTermination(ctx context.Context, org string, expiry uint64) (Dep, bool, error) {
+	d := Dep{Org: org}
+	tx, err := db.BeginTx(ctx, nil)
+	if err != nil {
+		return d, false, err
+	}
+	defer tx.Rollback() //nolint:errcheck
+	statement := `
+		SELECT expiry, provider, COALESCE(host(ip), ''), status, serverId, startBlock FROM deployments
+		WHERE org = $1
+		FOR UPDATE
+	`
+	row := tx.QueryRowContext(ctx, statement, org)
+	if err = row.Scan(&d.Expiry, &d.Provider, &d.IP, &d.Status, &d.ServerID, &d.StartBlock); err != nil {
+		return d, false, err
+	}
+	switch {
+	case d.Status == TerminatingStatus:
+		return d, true, nil
+	case d.Status == TerminatedStatus || d.Expiry > expiry:
+		return d, false, nil
+	}
+	reason := fmt.Sprintf("expired at block %d", expiry)
+	if err = transition(ctx, tx, org, d.Status, TerminatingStatus, reason); err != nil {
+		return d, false, err
+	}
+	d.Status = TerminatingStatus
+	return d, true, tx.Commit()
+}
+
+// MarkTerminated moves the deployment of org from terminating to terminated
+// and archives its lifetime. Its events are kept but those which paid for the
+// ended lifetime aren't processed or retried again.
+func MarkTerminated(ctx context.Context, org string, reason string) error {
+	return inTx(ctx, func(ex execer) error {
+		if err := transition(ctx, ex, org, TerminatingStatus, TerminatedStatus, reason); err != nil {
+			return err
+		}
+		if err := archive(ctx, ex, org); err != nil {
+			return err
+		}
+		statement := `
+			DELETE FROM failed_events f
+			USING events e
+			WHERE f.eventId = e.id AND e.org = $1
+			AND e.expiry <= (SELECT expiry FROM deployments WHERE org = $1)
+		`
+		if _, err := ex.ExecContext(ctx, statement, org); err != nil {
+			return err
+		}
+		statement = `
+			UPDATE events
+			SET processed = TRUE
+			WHERE org = $1 AND expiry <= (SELECT expiry FROM deployments WHERE org = $1)
+		`
+		_, err := ex.ExecContext(ctx, statement, org)
+		return err
+	})
+}
+
+// ListTransitions lists the transitions of org from oldest to newest
+func ListTransitions(ctx context.Context, org string) ([]TransitionRow, error) {
+	transitions := []TransitionRow{}
+	statement := `
+		SELECT fromStatus, toStatus, reason, createdAt FROM deployment_transitions
+		WHERE org = $1
+		ORDER BY id ASC
+	`
+	rows, err := db.QueryContext(ctx, statement, org)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var t TransitionRow
+	for rows.Next() {
+		if err = rows.Scan(&t.From, &t.To, &t.Reason, &t.CreatedAt); err != nil {
+			return nil, err
+		}
+		transitions = append(transitions, t)
+	}
+	if err = rows.Err(); err != nil {
+		return nil, err
+	}
+	return transitions, nil
+}
+
+// transition moves the status of org with ex if it's still from
+func transition(ctx context.Context, ex execer, org string, from string, to string, reason string) error {
+	if !CanTransition(from, to) {
+		return fmt.Errorf("%w from '%s' to '%s'", ErrInvalidTransition, from, to)
+	}
+	statement := `
+		UPDATE deployments
+		SET status = $3
+		WHERE org = $1 AND status = $2
+	`
+	res, err := ex.ExecContext(ctx, statement, org, from, to)
+	if err != nil {
+		return err
+	}
+	n, err := res.RowsAffected()
+	if err != nil {
+		return err
+	}
+	if n == 0 {
+		return fmt.Errorf("%w: org %s isn't '%s'", ErrStaleStatus, org, from)
+	}
+	return recordTransition(ctx, ex, org, from, to, reason)
+}
+
+func recordTransition(ctx context.Context, ex execer, org string, from string, to string, reason string) error {
+	statement := `
+		INSERT INTO deployment_transitions (org, fromStatus, toStatus, reason)
+		VALUES ($1, $2, $3, $4)
+	`
+	_, err := ex.ExecContext(ctx, statement, org, from, to, reason)
+	return err
+}
+
+// inTx runs fn in a transaction which is committed if fn succeeds
+func inTx(ctx context.Context, fn func(ex execer) error) error {
+	tx, err := db.BeginTx(ctx, nil)
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback() //nolint:errcheck
+	if err = fn(tx); err != nil {
+		return err
+	}
+	return tx.Commit()
+}
@@ -0,0 +1,48 @@
+// SPDX-License-Identifier: Apache-2.0
+
+package db
+
+import "testing"
+
+func TestCanTransition(t *testing.T) {
+	allowed := [][2]string{
+		{"", InitialStatus},
+		{InitialStatus, AllocatedStatus},
+		{AllocatedStatus, DNSCreatedStatus},
+		{DNSCreatedStatus, SetupFailedStatus},
+		{SetupFailedStatus, SetupFailedStatus},
+		{SetupFailedStatus, RunningStatus},
+		{RunningStatus, SuspendedStatus},
+		{SuspendedStatus, RunningStatus},
+		{InitialStatus, TerminatingStatus},
+		{TerminatingStatus, TerminatedStatus},
+		{TerminatedStatus, InitialStatus},
+	}
+	for _, tr := range allowed {
+		if !CanTransition(tr[0], tr[1]) {
+			t.Errorf("Expected: '%s' to '%s' to be allowed", tr[0], tr[1])
+		}
+	}
+
+	forbidden := [][2]string{
+		{"", RunningStatus},
+		{InitialStatus, RunningStatus},
+		{AllocatedStatus, RunningStatus},
+		{RunningStatus, InitialStatus},
+		{TerminatingStatus, RunningStatus},
+		{TerminatedStatus, RunningStatus},
+		{TerminatedStatus, TerminatingStatus},
+	}
+	for _, tr := range forbidden {
+		if CanTransition(tr[0], tr[1]) {
+			t.Errorf("Expected: '%s' to '%s' to be forbidden", tr[0], tr[1])
+		}
+	}
+
+	// everything short of terminating may be terminated
+	for from := range transitions {
+		if from != "" && from != TerminatingStatus && from != TerminatedStatus && !CanTransition(from, TerminatingStatus) {
+			t.Errorf("Expected: '%s' to be terminable", from)
+		}
+	}
+}
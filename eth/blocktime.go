@@ -0,0 +1,80 @@
+// SPDX-License-Identifier: Apache-2.0
+
+package eth
+
+import (
+	"os"
+	"sync"
+	"time"
+)
+
+// blockTimeWindow is how many recent headers the block interval is averaged over
+const blockTimeWindow = 64
+
+// DefaultBlockTime returns the block interval assumed until headers were seen
+func DefaultBlockTime() time.Duration {
+	v := os.Getenv("BLOCK_TIME")
+	if v == "" {
+		return 14 * time.Second
+	}
+	d, err := time.ParseDuration(v)
+	if err != nil || d <= 0 {
+		l.Fatal("Invalid BLOCK_TIME ", v)
+	}
+	return d
+}
+
+type blockSample struct {
+	number    uint64
+	timestamp uint64
+}
+
+// BlockTimer estimates the average block interval from recent headers
+type BlockTimer struct {
+	mu       sync.Mutex
+	samples  []blockSample
+	fallback time.Duration
+}
+
+// NewBlockTimer returns a BlockTimer assuming fallback until it observed headers
+func NewBlockTimer(fallback time.Duration) *BlockTimer {
+	return &BlockTimer{fallback: fallback}
+}
+
+// Observe records the header of block number mined at timestamp (unix seconds)
+func (b *BlockTimer) Observe(number uint64, timestamp uint64) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	// headers at or above number were reorged away
+	i := len(b.samples)
+	for i > 0 && b.samples[i-1].number >= number {
+		i--
+	}
+	b.samples = append(b.samples[:i], blockSample{number, timestamp})
+	// keep samples spanning at most blockTimeWindow blocks
+	for len(b.samples) > 2 && number-b.samples[1].number >= blockTimeWindow {
+		b.samples = b.samples[1:]
+	}
+}
+
+// Interval returns the average time between blocks
+func (b *BlockTimer) Interval() time.Duration {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	if len(b.samples) < 2 {
+		return b.fallback
+	}
+	first, last := b.samples[0], b.samples[len(b.samples)-1]
+	if last.timestamp <= first.timestamp {
+		return b.fallback
+	}
+	return time.Duration(last.timestamp-first.timestamp) * time.Second / time.Duration(last.number-first.number)
+}
+
+// Until returns the estimated time until block is mined when current is the head
+func (b *BlockTimer) Until(block uint64, current uint64) time.Duration {
+	if block <= current {
+		return 0
+	}
+	return time.Duration(block-current) * b.Interval()
+}
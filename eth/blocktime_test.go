@@ -0,0 +1,40 @@
+// SPDX-License-Identifier: Apache-2.0
+
+package eth
+
+import (
+	"testing"
+	"time"
+)
+
+func TestBlockTimer(t *testing.T) {
+	b := NewBlockTimer(14 * time.Second)
+	b.Observe(100, 1000)
+	if d := b.Interval(); d != 14*time.Second {
+		t.Fatalf("expected fallback with one sample, got %s", d)
+	}
+	// seeded from an older header, then new heads every 12s
+	b.Observe(36, 232)
+	if d := b.Interval(); d != 14*time.Second {
+		t.Fatalf("expected out of order header to reset samples, got %s", d)
+	}
+	b.Observe(100, 1000)
+	if d := b.Interval(); d != 12*time.Second {
+		t.Fatalf("expected 12s, got %s", d)
+	}
+	// a reorg replaces the header at 100
+	b.Observe(100, 1000)
+	b.Observe(101, 1012)
+	if d := b.Interval(); d != 12*time.Second {
+		t.Fatalf("expected 12s after reorg, got %s", d)
+	}
+	for n := uint64(102); n < 300; n++ {
+		b.Observe(n, 1012+(n-101)*2)
+	}
+	if d := b.Interval(); d != 2*time.Second {
+		t.Fatalf("expected old samples to age out, got %s", d)
+	}
+	if d := b.Until(310, 300); d != 20*time.Second {
+		t.Fatalf("expected 20s until block, got %s", d)
+	}
+}
@@ -0,0 +1,109 @@
+// SPDX-License-Identifier: Apache-2.0
+
+package eth
+
+import (
+	"context"
+	"errors"
+	"net/url"
+	"os"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/ethereum/go-ethereum/ethclient"
+	"github.com/ethereum/go-ethereum/rpc"
+)
+
+// ErrNoEndpoints is returned when dialing an empty list of endpoints
+var ErrNoEndpoints = errors.New("no RPC endpoints configured")
+
+// Endpoints is a list of RPC urls to fail over between, the current one is
+// used until it fails
+type Endpoints struct {
+	mu      sync.Mutex
+	urls    []string
+	current int
+}
+
+// NewEndpoints parses a comma separated list of ws(s) or http(s) urls
+func NewEndpoints(list string) *Endpoints {
+	e := &Endpoints{}
+	for _, u := range strings.Split(list, ",") {
+		if u = strings.TrimSpace(u); u != "" {
+			e.urls = append(e.urls, u)
+		}
+	}
+	return e
+}
+
+// Dial connects to the first reachable endpoint starting with the current one
+// and returns the client and the url it's connected to
+func (e *Endpoints) Dial(ctx context.Context) (*ethclient.Client, string, error) {
+	rc, rawurl, err := e.DialRPC(ctx)
+	if err != nil {
+		return nil, "", err
+	}
+	return ethclient.NewClient(rc), rawurl, nil
+}
+
+// DialRPC is like Dial but returns the raw RPC client for calls ethclient
+// doesn't cover
+func (e *Endpoints) DialRPC(ctx context.Context) (*rpc.Client, string, error) {
+	e.mu.Lock()
+	defer e.mu.Unlock()
+	if len(e.urls) == 0 {
+		return nil, "", ErrNoEndpoints
+	}
+	var err error
+	for i := 0; i < len(e.urls); i++ {
+		idx := (e.current + i) % len(e.urls)
+		var client *rpc.Client
+		client, err = rpc.DialContext(ctx, e.urls[idx])
+		if err == nil {
+			e.current = idx
+			return client, e.urls[idx], nil
+		}
+		l.Printf("Failed to dial %s: %v\n", Redact(e.urls[idx]), err)
+		if ctx.Err() != nil {
+			break
+		}
+	}
+	return nil, "", err
+}
+
+// Failed moves on to the next endpoint after rawurl has failed
+func (e *Endpoints) Failed(rawurl string) {
+	e.mu.Lock()
+	defer e.mu.Unlock()
+	if len(e.urls) > 0 && e.urls[e.current] == rawurl {
+		e.current = (e.current + 1) % len(e.urls)
+	}
+}
+
+// Redact strips the path of rawurl which usually carries the API key
+func Redact(rawurl string) string {
+	u, err := url.Parse(rawurl)
+	if err != nil || u.Host == "" {
+		return "<invalid url>"
+	}
+	return u.Scheme + "://" + u.Host
+}
+
+// isPolling reports whether rawurl doesn't support subscriptions
+func isPolling(rawurl string) bool {
+	return strings.HasPrefix(rawurl, "http://") || strings.HasPrefix(rawurl, "https://")
+}
+
+// PollInterval returns how often http endpoints are polled for new logs and heads
+func PollInterval() time.Duration {
+	v := os.Getenv("CHAIN_POLL_INTERVAL")
+	if v == "" {
+		return 15 * time.Second
+	}
+	d, err := time.ParseDuration(v)
+	if err != nil || d <= 0 {
+		l.Fatal("Invalid CHAIN_POLL_INTERVAL ", v)
+	}
+	return d
+}
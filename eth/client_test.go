@@ -0,0 +1,27 @@
+// SPDX-License-Identifier: Apache-2.0
+
+package eth
+
+import "testing"
+
+func TestEndpointsFailover(t *testing.T) {
+	e := NewEndpoints(" wss://a.example/v2/key, https://b.example/v2/key ,")
+	if len(e.urls) != 2 {
+		t.Fatalf("expected 2 urls, got %v", e.urls)
+	}
+	e.Failed("wss://other.example")
+	if e.current != 0 {
+		t.Fatalf("unknown url moved current to %d", e.current)
+	}
+	e.Failed(e.urls[0])
+	if e.urls[e.current] != "https://b.example/v2/key" || !isPolling(e.urls[e.current]) {
+		t.Fatalf("expected failover to polling endpoint, got %s", e.urls[e.current])
+	}
+	e.Failed(e.urls[1])
+	if e.current != 0 {
+		t.Fatalf("expected wrap around, got %d", e.current)
+	}
+	if r := Redact(e.urls[0]); r != "wss://a.example" {
+		t.Fatalf("unexpected redaction %s", r)
+	}
+}
@@ -0,0 +1,113 @@
+// SPDX-License-Identifier: Apache-2.0
+
+package eth
+
+import (
+	"os"
+	"sort"
+	"strconv"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/core/types"
+)
+
+// ConfirmationDepth returns how many blocks deep a log must be before it's
+// released to provisioning, 0 releases logs right away
+func ConfirmationDepth() uint64 {
+	v := os.Getenv("CONFIRMATION_DEPTH")
+	if v == "" {
+		return 0
+	}
+	depth, err := strconv.ParseUint(v, 10, 64)
+	if err != nil {
+		l.Fatal("Invalid CONFIRMATION_DEPTH ", v)
+	}
+	return depth
+}
+
+// staleBlocks is how long logs of a block which was found to be orphaned are
+// held after they'd have been confirmed, in case their removal never arrives
+// e.g. when polling
+const staleBlocks = 128
+
+type logKey struct {
+	blockHash common.Hash
+	index     uint
+}
+
+// confirmer buffers logs until they're depth blocks deep
+type confirmer struct {
+	depth   uint64
+	pending map[logKey]types.Log
+}
+
+func newConfirmer(depth uint64) *confirmer {
+	return &confirmer{depth: depth, pending: map[logKey]types.Log{}}
+}
+
+// add buffers a new log or cancels a buffered one if log is removed. It
+// returns false for removed logs which were never buffered, those were
+// released already and need to be handled downstream.
+func (cf *confirmer) add(log types.Log) bool {
+	key := logKey{log.BlockHash, log.Index}
+	if log.Removed {
+		if _, ok := cf.pending[key]; ok {
+			delete(cf.pending, key)
+			return true
+		}
+		return false
+	}
+	cf.pending[key] = log
+	return true
+}
+
+// release returns buffered logs which are confirmed at head, ordered as they
+// happened on chain. Logs whose block turns out to be orphaned can be added
+// back to be held until their removal arrives, and are dropped once stale.
+func (cf *confirmer) release(head uint64) []types.Log {
+	released := []types.Log{}
+	for key, log := range cf.pending {
+		if log.BlockNumber+cf.depth+staleBlocks <= head+1 {
+			l.Printf("Dropped log of orphaned block %d in tx=%s, its removal never arrived\n", log.BlockNumber, log.TxHash.Hex())
+			delete(cf.pending, key)
+			continue
+		}
+		// the log's block counts as its first confirmation
+		if log.BlockNumber+cf.depth <= head+1 {
+			released = append(released, log)
+			delete(cf.pending, key)
+		}
+	}
+	sort.Slice(released, func(i, j int) bool {
+		if released[i].BlockNumber != released[j].BlockNumber {
+			return released[i].BlockNumber < released[j].BlockNumber
+		}
+		return released[i].Index < released[j].Index
+	})
+	return released
+}
+
+// oldest returns the block of the oldest buffered log
+func (cf *confirmer) oldest() (uint64, bool) {
+	oldest, ok := uint64(0), false
+	for _, log := range cf.pending {
+		if !ok || log.BlockNumber < oldest {
+			oldest, ok = log.BlockNumber, true
+		}
+	}
+	return oldest, ok
+}
+
+// drop removes buffered logs of block from onwards
+func (cf *confirmer) drop(from uint64) {
+	for key, log := range cf.pending {
+		if log.BlockNumber >= from {
+			delete(cf.pending, key)
+		}
+	}
+}
+
+// len returns number of buffered logs
+func (cf *confirmer) len() int {
+	return len(cf.pending)
+}
@@ -0,0 +1,50 @@
+// SPDX-License-Identifier: Apache-2.0
+
+package eth
+
+import (
+	"testing"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/core/types"
+)
+
+func TestConfirmer(t *testing.T) {
+	cf := newConfirmer(3)
+	a := types.Log{BlockNumber: 10, BlockHash: common.HexToHash("0x0a"), Index: 1}
+	b := types.Log{BlockNumber: 10, BlockHash: common.HexToHash("0x0a"), Index: 0}
+	c := types.Log{BlockNumber: 11, BlockHash: common.HexToHash("0x0b"), Index: 0}
+	cf.add(a)
+	cf.add(b)
+	cf.add(c)
+
+	if released := cf.release(11); len(released) != 0 {
+		t.Errorf("Expected: nothing at 11, Actual: %v", released)
+	}
+
+	released := cf.release(12)
+	if len(released) != 2 || released[0].Index != 0 || released[1].Index != 1 {
+		t.Errorf("Expected: both logs of block 10 in order, Actual: %v", released)
+	}
+
+	// orphaned before being confirmed
+	c.Removed = true
+	if !cf.add(c) {
+		t.Error("Expected: removed log to cancel the buffered one")
+	}
+	if cf.len() != 0 {
+		t.Errorf("Expected: empty buffer, Actual: %d", cf.len())
+	}
+
+	// removed after being released must be handled downstream
+	a.Removed = true
+	if cf.add(a) {
+		t.Error("Expected: removed released log not to be cancelled")
+	}
+
+	// an orphaned log held back is dropped once it's stale
+	cf.add(b)
+	if released := cf.release(12 + staleBlocks); len(released) != 0 || cf.len() != 0 {
+		t.Errorf("Expected: stale log to be dropped, Actual: %v", released)
+	}
+}
@@ -0,0 +1,1244 @@
+// Code generated - DO NOT EDIT.
+// This file is a generated binding and any manual changes will be lost.
+
+package contract
+
+import (
+	"errors"
+	"math/big"
+	"strings"
+
+	ethereum "github.com/ethereum/go-ethereum"
+	"github.com/ethereum/go-ethereum/accounts/abi"
+	"github.com/ethereum/go-ethereum/accounts/abi/bind"
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/core/types"
+	"github.com/ethereum/go-ethereum/event"
+)
+
+// Reference imports to suppress errors if they are not otherwise used.
+var (
+	_ = errors.New
+	_ = big.NewInt
+	_ = strings.NewReader
+	_ = ethereum.NotFound
+	_ = bind.Bind
+	_ = common.Big1
+	_ = types.BloomLookup
+	_ = event.NewSubscription
+)
+
+// RadicleCloudMetaData contains all meta data concerning the RadicleCloud contract.
+var RadicleCloudMetaData = &bind.MetaData{
+	ABI: "[{\"inputs\":[{\"internalType\":\"uint64\",\"name\":\"price\",\"type\":\"uint64\"},{\"internalType\":\"uint32\",\"name\":\"_duration\",\"type\":\"uint32\"},{\"internalType\":\"address\",\"name\":\"_owner\",\"type\":\"address\"}],\"stateMutability\":\"nonpayable\",\"type\":\"constructor\"},{\"anonymous\":false,\"inputs\":[{\"internalType\":\"address\",\"name\":\"org\",\"type\":\"address\",\"indexed\":false},{\"internalType\":\"address\",\"name\":\"owner\",\"type\":\"address\",\"indexed\":false}],\"name\":\"DeploymentOwnerChanged\",\"type\":\"event\"},{\"anonymous\":false,\"inputs\":[{\"internalType\":\"address\",\"name\":\"org\",\"type\":\"address\",\"indexed\":false},{\"internalType\":\"uint64\",\"name\":\"expiry\",\"type\":\"uint64\",\"indexed\":false}],\"name\":\"DeploymentStopped\",\"type\":\"event\"},{\"anonymous\":false,\"inputs\":[{\"internalType\":\"address\",\"name\":\"org\",\"type\":\"address\",\"indexed\":false},{\"internalType\":\"uint64\",\"name\":\"expiry\",\"type\":\"uint64\",\"indexed\":false}],\"name\":\"NewTopUp\",\"type\":\"event\"},{\"anonymous\":false,\"inputs\":[{\"internalType\":\"address\",\"name\":\"owner\",\"type\":\"address\",\"indexed\":false}],\"name\":\"OwnerChanged\",\"type\":\"event\"},{\"anonymous\":false,\"inputs\":[{\"internalType\":\"uint64\",\"name\":\"rate\",\"type\":\"uint64\",\"indexed\":false}],\"name\":\"RateChanged\",\"type\":\"event\"},{\"inputs\":[{\"internalType\":\"address\",\"name\":\"org\",\"type\":\"address\"}],\"name\":\"cancelDeployment\",\"outputs\":[],\"stateMutability\":\"nonpayable\",\"type\":\"function\"},{\"inputs\":[{\"internalType\":\"address\",\"name\":\"newOwner\",\"type\":\"address\"}],\"name\":\"changeOwner\",\"outputs\":[],\"stateMutability\":\"nonpayable\",\"type\":\"function\"},{\"inputs\":[{\"internalType\":\"uint64\",\"name\":\"newRate\",\"type\":\"uint64\"}],\"name\":\"changeRate\",\"outputs\":[],\"stateMutability\":\"nonpayable\",\"type\":\"function\"},{\"inputs\":[{\"internalType\":\"address\",\"name\":\"\",\"type\":\"address\"}],\"name\":\"dep\",\"outputs\":[{\"internalType\":\"uint64\",\"name\":\"start\",\"type\":\"uint64\"},{\"internalType\":\"uint64\",\"name\":\"expiry\",\"type\":\"uint64\"},{\"internalType\":\"address\",\"name\":\"owner\",\"type\":\"address\"}],\"stateMutability\":\"view\",\"type\":\"function\"},{\"inputs\":[],\"name\":\"duration\",\"outputs\":[{\"internalType\":\"uint32\",\"name\":\"\",\"type\":\"uint32\"}],\"stateMutability\":\"view\",\"type\":\"function\"},{\"inputs\":[{\"internalType\":\"address\",\"name\":\"org\",\"type\":\"address\"}],\"name\":\"getExpiry\",\"outputs\":[{\"internalType\":\"uint64\",\"name\":\"\",\"type\":\"uint64\"}],\"stateMutability\":\"view\",\"type\":\"function\"},{\"inputs\":[],\"name\":\"getPrice\",\"outputs\":[{\"internalType\":\"uint256\",\"name\":\"\",\"type\":\"uint256\"}],\"stateMutability\":\"view\",\"type\":\"function\"},{\"inputs\":[],\"name\":\"owner\",\"outputs\":[{\"internalType\":\"address\",\"name\":\"\",\"type\":\"address\"}],\"stateMutability\":\"view\",\"type\":\"function\"},{\"inputs\":[{\"internalType\":\"address\",\"name\":\"\",\"type\":\"address\"},{\"internalType\":\"uint256\",\"name\":\"\",\"type\":\"uint256\"}],\"name\":\"pkgs\",\"outputs\":[{\"internalType\":\"uint64\",\"name\":\"start\",\"type\":\"uint64\"},{\"internalType\":\"uint64\",\"name\":\"expiry\",\"type\":\"uint64\"},{\"internalType\":\"uint64\",\"name\":\"rate\",\"type\":\"uint64\"}],\"stateMutability\":\"view\",\"type\":\"function\"},{\"inputs\":[],\"name\":\"ratePerBlock\",\"outputs\":[{\"internalType\":\"uint64\",\"name\":\"\",\"type\":\"uint64\"}],\"stateMutability\":\"view\",\"type\":\"function\"},{\"inputs\":[{\"internalType\":\"address\",\"name\":\"org\",\"type\":\"address\"}],\"name\":\"suspendDeployment\",\"outputs\":[],\"stateMutability\":\"nonpayable\",\"type\":\"function\"},{\"inputs\":[],\"name\":\"withdrawRealizedRevenue\",\"outputs\":[{\"internalType\":\"uint128\",\"name\":\"amount\",\"type\":\"uint128\"}],\"stateMutability\":\"nonpayable\",\"type\":\"function\"},{\"inputs\":[],\"name\":\"withdrawWait\",\"outputs\":[{\"internalType\":\"uint32\",\"name\":\"\",\"type\":\"uint32\"}],\"stateMutability\":\"view\",\"type\":\"function\"}]",
+}
+
+// RadicleCloudABI is the input ABI used to generate the binding from.
+// Deprecated: Use RadicleCloudMetaData.ABI instead.
+var RadicleCloudABI = RadicleCloudMetaData.ABI
+
+// RadicleCloud is an auto generated Go binding around an Ethereum contract.
+type RadicleCloud struct {
+	RadicleCloudCaller     // Read-only binding to the contract
+	RadicleCloudTransactor // Write-only binding to the contract
+	RadicleCloudFilterer   // Log filterer for contract events
+}
+
+// RadicleCloudCaller is an auto generated read-only Go binding around an Ethereum contract.
+type RadicleCloudCaller struct {
+	contract *bind.BoundContract // Generic contract wrapper for the low level calls
+}
+
+// RadicleCloudTransactor is an auto generated write-only Go binding around an Ethereum contract.
+type RadicleCloudTransactor struct {
+	contract *bind.BoundContract // Generic contract wrapper for the low level calls
+}
+
+// RadicleCloudFilterer is an auto generated log filtering Go binding around an Ethereum contract events.
+type RadicleCloudFilterer struct {
+	contract *bind.BoundContract // Generic contract wrapper for the low level calls
+}
+
+// RadicleCloudSession is an auto generated Go binding around an Ethereum contract,
+// with pre-set call and transact options.
+type RadicleCloudSession struct {
+	Contract     *RadicleCloud     // Generic contract binding to set the session for
+	CallOpts     bind.CallOpts     // Call options to use throughout this session
+	TransactOpts bind.TransactOpts // Transaction auth options to use throughout this session
+}
+
+// RadicleCloudCallerSession is an auto generated read-only Go binding around an Ethereum contract,
+// with pre-set call options.
+type RadicleCloudCallerSession struct {
+	Contract *RadicleCloudCaller // Generic contract caller binding to set the session for
+	CallOpts bind.CallOpts       // Call options to use throughout this session
+}
+
+// RadicleCloudTransactorSession is an auto generated write-only Go binding around an Ethereum contract,
+// with pre-set transact options.
+type RadicleCloudTransactorSession struct {
+	Contract     *RadicleCloudTransactor // Generic contract transactor binding to set the session for
+	TransactOpts bind.TransactOpts       // Transaction auth options to use throughout this session
+}
+
+// RadicleCloudRaw is an auto generated low-level Go binding around an Ethereum contract.
+type RadicleCloudRaw struct {
+	Contract *RadicleCloud // Generic contract binding to access the raw methods on
+}
+
+// RadicleCloudCallerRaw is an auto generated low-level read-only Go binding around an Ethereum contract.
+type RadicleCloudCallerRaw struct {
+	Contract *RadicleCloudCaller // Generic read-only contract binding to access the raw methods on
+}
+
+// RadicleCloudTransactorRaw is an auto generated low-level write-only Go binding around an Ethereum contract.
+type RadicleCloudTransactorRaw struct {
+	Contract *RadicleCloudTransactor // Generic write-only contract binding to access the raw methods on
+}
+
+// NewRadicleCloud creates a new instance of RadicleCloud, bound to a specific deployed contract.
+func NewRadicleCloud(address common.Address, backend bind.ContractBackend) (*RadicleCloud, error) {
+	contract, err := bindRadicleCloud(address, backend, backend, backend)
+	if err != nil {
+		return nil, err
+	}
+	return &RadicleCloud{RadicleCloudCaller: RadicleCloudCaller{contract: contract}, RadicleCloudTransactor: RadicleCloudTransactor{contract: contract}, RadicleCloudFilterer: RadicleCloudFilterer{contract: contract}}, nil
+}
+
+// NewRadicleCloudCaller creates a new read-only instance of RadicleCloud, bound to a specific deployed contract.
+func NewRadicleCloudCaller(address common.Address, caller bind.ContractCaller) (*RadicleCloudCaller, error) {
+	contract, err := bindRadicleCloud(address, caller, nil, nil)
+	if err != nil {
+		return nil, err
+	}
+	return &RadicleCloudCaller{contract: contract}, nil
+}
+
+// NewRadicleCloudTransactor creates a new write-only instance of RadicleCloud, bound to a specific deployed contract.
+func NewRadicleCloudTransactor(address common.Address, transactor bind.ContractTransactor) (*RadicleCloudTransactor, error) {
+	contract, err := bindRadicleCloud(address, nil, transactor, nil)
+	if err != nil {
+		return nil, err
+	}
+	return &RadicleCloudTransactor{contract: contract}, nil
+}
+
+// NewRadicleCloudFilterer creates a new log filterer instance of RadicleCloud, bound to a specific deployed contract.
+func NewRadicleCloudFilterer(address common.Address, filterer bind.ContractFilterer) (*RadicleCloudFilterer, error) {
+	contract, err := bindRadicleCloud(address, nil, nil, filterer)
+	if err != nil {
+		return nil, err
+	}
+	return &RadicleCloudFilterer{contract: contract}, nil
+}
+
+// bindRadicleCloud binds a generic wrapper to an already deployed contract.
+func bindRadicleCloud(address common.Address, caller bind.ContractCaller, transactor bind.ContractTransactor, filterer bind.ContractFilterer) (*bind.BoundContract, error) {
+	parsed, err := abi.JSON(strings.NewReader(RadicleCloudABI))
+	if err != nil {
+		return nil, err
+	}
+	return bind.NewBoundContract(address, parsed, caller, transactor, filterer), nil
+}
+
+// Call invokes the (constant) contract method with params as input values and
+// sets the output to result. The result type might be a single field for simple
+// returns, a slice of interfaces for anonymous returns and a struct for named
+// returns.
+func (_RadicleCloud *RadicleCloudRaw) Call(opts *bind.CallOpts, result *[]interface{}, method string, params ...interface{}) error {
+	return _RadicleCloud.Contract.RadicleCloudCaller.contract.Call(opts, result, method, params...)
+}
+
+// Transfer initiates a plain transaction to move funds to the contract, calling
+// its default method if one is available.
+func (_RadicleCloud *RadicleCloudRaw) Transfer(opts *bind.TransactOpts) (*types.Transaction, error) {
+	return _RadicleCloud.Contract.RadicleCloudTransactor.contract.Transfer(opts)
+}
+
+// Transact invokes the (paid) contract method with params as input values.
+func (_RadicleCloud *RadicleCloudRaw) Transact(opts *bind.TransactOpts, method string, params ...interface{}) (*types.Transaction, error) {
+	return _RadicleCloud.Contract.RadicleCloudTransactor.contract.Transact(opts, method, params...)
+}
+
+// Call invokes the (constant) contract method with params as input values and
+// sets the output to result. The result type might be a single field for simple
+// returns, a slice of interfaces for anonymous returns and a struct for named
+// returns.
+func (_RadicleCloud *RadicleCloudCallerRaw) Call(opts *bind.CallOpts, result *[]interface{}, method string, params ...interface{}) error {
+	return _RadicleCloud.Contract.contract.Call(opts, result, method, params...)
+}
+
+// Transfer initiates a plain transaction to move funds to the contract, calling
+// its default method if one is available.
+func (_RadicleCloud *RadicleCloudTransactorRaw) Transfer(opts *bind.TransactOpts) (*types.Transaction, error) {
+	return _RadicleCloud.Contract.contract.Transfer(opts)
+}
+
+// Transact invokes the (paid) contract method with params as input values.
+func (_RadicleCloud *RadicleCloudTransactorRaw) Transact(opts *bind.TransactOpts, method string, params ...interface{}) (*types.Transaction, error) {
+	return _RadicleCloud.Contract.contract.Transact(opts, method, params...)
+}
+
+// Dep is a free data retrieval call binding the contract method 0xb733e791.
+//
+// Solidity: function dep(address ) view returns(uint64 start, uint64 expiry, address owner)
+func (_RadicleCloud *RadicleCloudCaller) Dep(opts *bind.CallOpts, arg0 common.Address) (struct {
+	Start  uint64
+	Expiry uint64
+	Owner  common.Address
+}, error) {
+	var out []interface{}
+	err := _RadicleCloud.contract.Call(opts, &out, "dep", arg0)
+
+	outstruct := new(struct {
+		Start  uint64
+		Expiry uint64
+		Owner  common.Address
+	})
+	if err != nil {
+		return *outstruct, err
+	}
+
+	outstruct.Start = *abi.ConvertType(out[0], new(uint64)).(*uint64)
+	outstruct.Expiry = *abi.ConvertType(out[1], new(uint64)).(*uint64)
+	outstruct.Owner = *abi.ConvertType(out[2], new(common.Address)).(*common.Address)
+
+	return *outstruct, err
+
+}
+
+// Dep is a free data retrieval call binding the contract method 0xb733e791.
+//
+// Solidity: function dep(address ) view returns(uint64 start, uint64 expiry, address owner)
+func (_RadicleCloud *RadicleCloudSession) Dep(arg0 common.Address) (struct {
+	Start  uint64
+	Expiry uint64
+	Owner  common.Address
+}, error) {
+	return _RadicleCloud.Contract.Dep(&_RadicleCloud.CallOpts, arg0)
+}
+
+// Dep is a free data retrieval call binding the contract method 0xb733e791.
+//
+// Solidity: function dep(address ) view returns(uint64 start, uint64 expiry, address owner)
+func (_RadicleCloud *RadicleCloudCallerSession) Dep(arg0 common.Address) (struct {
+	Start  uint64
+	Expiry uint64
+	Owner  common.Address
+}, error) {
+	return _RadicleCloud.Contract.Dep(&_RadicleCloud.CallOpts, arg0)
+}
+
+// Duration is a free data retrieval call binding the contract method 0x0fb5a6b4.
+//
+// Solidity: function duration() view returns(uint32)
+func (_RadicleCloud *RadicleCloudCaller) Duration(opts *bind.CallOpts) (uint32, error) {
+	var out []interface{}
+	err := _RadicleCloud.contract.Call(opts, &out, "duration")
+
+	if err != nil {
+		return *new(uint32), err
+	}
+
+	out0 := *abi.ConvertType(out[0], new(uint32)).(*uint32)
+
+	return out0, err
+
+}
+
+// Duration is a free data retrieval call binding the contract method 0x0fb5a6b4.
+//
+// Solidity: function duration() view returns(uint32)
+func (_RadicleCloud *RadicleCloudSession) Duration() (uint32, error) {
+	return _RadicleCloud.Contract.Duration(&_RadicleCloud.CallOpts)
+}
+
+// Duration is a free data retrieval call binding the contract method 0x0fb5a6b4.
+//
+// Solidity: function duration() view returns(uint32)
+func (_RadicleCloud *RadicleCloudCallerSession) Duration() (uint32, error) {
+	return _RadicleCloud.Contract.Duration(&_RadicleCloud.CallOpts)
+}
+
+// GetExpiry is a free data retrieval call binding the contract method 0x726fb2a5.
+//
+// Solidity: function getExpiry(address org) view returns(uint64)
+func (_RadicleCloud *RadicleCloudCaller) GetExpiry(opts *bind.CallOpts, org common.Address) (uint64, error) {
+	var out []interface{}
+	err := _RadicleCloud.contract.Call(opts, &out, "getExpiry", org)
+
+	if err != nil {
+		return *new(uint64), err
+	}
+
+	out0 := *abi.ConvertType(out[0], new(uint64)).(*uint64)
+
+	return out0, err
+
+}
+
+// GetExpiry is a free data retrieval call binding the contract method 0x726fb2a5.
+//
+// Solidity: function getExpiry(address org) view returns(uint64)
+func (_RadicleCloud *RadicleCloudSession) GetExpiry(org common.Address) (uint64, error) {
+	return _RadicleCloud.Contract.GetExpiry(&_RadicleCloud.CallOpts, org)
+}
+
+// GetExpiry is a free data retrieval call binding the contract method 0x726fb2a5.
+//
+// Solidity: function getExpiry(address org) view returns(uint64)
+func (_RadicleCloud *RadicleCloudCallerSession) GetExpiry(org common.Address) (uint64, error) {
+	return _RadicleCloud.Contract.GetExpiry(&_RadicleCloud.CallOpts, org)
+}
+
+// GetPrice is a free data retrieval call binding the contract method 0x98d5fdca.
+//
+// Solidity: function getPrice() view returns(uint256)
+func (_RadicleCloud *RadicleCloudCaller) GetPrice(opts *bind.CallOpts) (*big.Int, error) {
+	var out []interface{}
+	err := _RadicleCloud.contract.Call(opts, &out, "getPrice")
+
+	if err != nil {
+		return *new(*big.Int), err
+	}
+
+	out0 := *abi.ConvertType(out[0], new(*big.Int)).(**big.Int)
+
+	return out0, err
+
+}
+
+// GetPrice is a free data retrieval call binding the contract method 0x98d5fdca.
+//
+// Solidity: function getPrice() view returns(uint256)
+func (_RadicleCloud *RadicleCloudSession) GetPrice() (*big.Int, error) {
+	return _RadicleCloud.Contract.GetPrice(&_RadicleCloud.CallOpts)
+}
+
+// GetPrice is a free data retrieval call binding the contract method 0x98d5fdca.
+//
+// Solidity: function getPrice() view returns(uint256)
+func (_RadicleCloud *RadicleCloudCallerSession) GetPrice() (*big.Int, error) {
+	return _RadicleCloud.Contract.GetPrice(&_RadicleCloud.CallOpts)
+}
+
+// Owner is a free data retrieval call binding the contract method 0x8da5cb5b.
+//
+// Solidity: function owner() view returns(address)
+func (_RadicleCloud *RadicleCloudCaller) Owner(opts *bind.CallOpts) (common.Address, error) {
+	var out []interface{}
+	err := _RadicleCloud.contract.Call(opts, &out, "owner")
+
+	if err != nil {
+		return *new(common.Address), err
+	}
+
+	out0 := *abi.ConvertType(out[0], new(common.Address)).(*common.Address)
+
+	return out0, err
+
+}
+
+// Owner is a free data retrieval call binding the contract method 0x8da5cb5b.
+//
+// Solidity: function owner() view returns(address)
+func (_RadicleCloud *RadicleCloudSession) Owner() (common.Address, error) {
+	return _RadicleCloud.Contract.Owner(&_RadicleCloud.CallOpts)
+}
+
+// Owner is a free data retrieval call binding the contract method 0x8da5cb5b.
+//
+// Solidity: function owner() view returns(address)
+func (_RadicleCloud *RadicleCloudCallerSession) Owner() (common.Address, error) {
+	return _RadicleCloud.Contract.Owner(&_RadicleCloud.CallOpts)
+}
+
+// Pkgs is a free data retrieval call binding the contract method 0x5cba7bd7.
+//
+// Solidity: function pkgs(address , uint256 ) view returns(uint64 start, uint64 expiry, uint64 rate)
+func (_RadicleCloud *RadicleCloudCaller) Pkgs(opts *bind.CallOpts, arg0 common.Address, arg1 *big.Int) (struct {
+	Start  uint64
+	Expiry uint64
+	Rate   uint64
+}, error) {
+	var out []interface{}
+	err := _RadicleCloud.contract.Call(opts, &out, "pkgs", arg0, arg1)
+
+	outstruct := new(struct {
+		Start  uint64
+		Expiry uint64
+		Rate   uint64
+	})
+	if err != nil {
+		return *outstruct, err
+	}
+
+	outstruct.Start = *abi.ConvertType(out[0], new(uint64)).(*uint64)
+	outstruct.Expiry = *abi.ConvertType(out[1], new(uint64)).(*uint64)
+	outstruct.Rate = *abi.ConvertType(out[2], new(uint64)).(*uint64)
+
+	return *outstruct, err
+
+}
+
+// Pkgs is a free data retrieval call binding the contract method 0x5cba7bd7.
+//
+// Solidity: function pkgs(address , uint256 ) view returns(uint64 start, uint64 expiry, uint64 rate)
+func (_RadicleCloud *RadicleCloudSession) Pkgs(arg0 common.Address, arg1 *big.Int) (struct {
+	Start  uint64
+	Expiry uint64
+	Rate   uint64
+}, error) {
+	return _RadicleCloud.Contract.Pkgs(&_RadicleCloud.CallOpts, arg0, arg1)
+}
+
+// Pkgs is a free data retrieval call binding the contract method 0x5cba7bd7.
+//
+// Solidity: function pkgs(address , uint256 ) view returns(uint64 start, uint64 expiry, uint64 rate)
+func (_RadicleCloud *RadicleCloudCallerSession) Pkgs(arg0 common.Address, arg1 *big.Int) (struct {
+	Start  uint64
+	Expiry uint64
+	Rate   uint64
+}, error) {
+	return _RadicleCloud.Contract.Pkgs(&_RadicleCloud.CallOpts, arg0, arg1)
+}
+
+// RatePerBlock is a free data retrieval call binding the contract method 0x34a4b0a5.
+//
+// Solidity: function ratePerBlock() view returns(uint64)
+func (_RadicleCloud *RadicleCloudCaller) RatePerBlock(opts *bind.CallOpts) (uint64, error) {
+	var out []interface{}
+	err := _RadicleCloud.contract.Call(opts, &out, "ratePerBlock")
+
+	if err != nil {
+		return *new(uint64), err
+	}
+
+	out0 := *abi.ConvertType(out[0], new(uint64)).(*uint64)
+
+	return out0, err
+
+}
+
+// RatePerBlock is a free data retrieval call binding the contract method 0x34a4b0a5.
+//
+// Solidity: function ratePerBlock() view returns(uint64)
+func (_RadicleCloud *RadicleCloudSession) RatePerBlock() (uint64, error) {
+	return _RadicleCloud.Contract.RatePerBlock(&_RadicleCloud.CallOpts)
+}
+
+// RatePerBlock is a free data retrieval call binding the contract method 0x34a4b0a5.
+//
+// Solidity: function ratePerBlock() view returns(uint64)
+func (_RadicleCloud *RadicleCloudCallerSession) RatePerBlock() (uint64, error) {
+	return _RadicleCloud.Contract.RatePerBlock(&_RadicleCloud.CallOpts)
+}
+
+// WithdrawWait is a free data retrieval call binding the contract method 0x20fea064.
+//
+// Solidity: function withdrawWait() view returns(uint32)
+func (_RadicleCloud *RadicleCloudCaller) WithdrawWait(opts *bind.CallOpts) (uint32, error) {
+	var out []interface{}
+	err := _RadicleCloud.contract.Call(opts, &out, "withdrawWait")
+
+	if err != nil {
+		return *new(uint32), err
+	}
+
+	out0 := *abi.ConvertType(out[0], new(uint32)).(*uint32)
+
+	return out0, err
+
+}
+
+// WithdrawWait is a free data retrieval call binding the contract method 0x20fea064.
+//
+// Solidity: function withdrawWait() view returns(uint32)
+func (_RadicleCloud *RadicleCloudSession) WithdrawWait() (uint32, error) {
+	return _RadicleCloud.Contract.WithdrawWait(&_RadicleCloud.CallOpts)
+}
+
+// WithdrawWait is a free data retrieval call binding the contract method 0x20fea064.
+//
+// Solidity: function withdrawWait() view returns(uint32)
+func (_RadicleCloud *RadicleCloudCallerSession) WithdrawWait() (uint32, error) {
+	return _RadicleCloud.Contract.WithdrawWait(&_RadicleCloud.CallOpts)
+}
+
+// CancelDeployment is a paid mutator transaction binding the contract method 0xf8535246.
+//
+// Solidity: function cancelDeployment(address org) returns()
+func (_RadicleCloud *RadicleCloudTransactor) CancelDeployment(opts *bind.TransactOpts, org common.Address) (*types.Transaction, error) {
+	return _RadicleCloud.contract.Transact(opts, "cancelDeployment", org)
+}
+
+// CancelDeployment is a paid mutator transaction binding the contract method 0xf8535246.
+//
+// Solidity: function cancelDeployment(address org) returns()
+func (_RadicleCloud *RadicleCloudSession) CancelDeployment(org common.Address) (*types.Transaction, error) {
+	return _RadicleCloud.Contract.CancelDeployment(&_RadicleCloud.TransactOpts, org)
+}
+
+// CancelDeployment is a paid mutator transaction binding the contract method 0xf8535246.
+//
+// Solidity: function cancelDeployment(address org) returns()
+func (_RadicleCloud *RadicleCloudTransactorSession) CancelDeployment(org common.Address) (*types.Transaction, error) {
+	return _RadicleCloud.Contract.CancelDeployment(&_RadicleCloud.TransactOpts, org)
+}
+
+// ChangeOwner is a paid mutator transaction binding the contract method 0xa6f9dae1.
+//
+// Solidity: function changeOwner(address newOwner) returns()
+func (_RadicleCloud *RadicleCloudTransactor) ChangeOwner(opts *bind.TransactOpts, newOwner common.Address) (*types.Transaction, error) {
+	return _RadicleCloud.contract.Transact(opts, "changeOwner", newOwner)
+}
+
+// ChangeOwner is a paid mutator transaction binding the contract method 0xa6f9dae1.
+//
+// Solidity: function changeOwner(address newOwner) returns()
+func (_RadicleCloud *RadicleCloudSession) ChangeOwner(newOwner common.Address) (*types.Transaction, error) {
+	return _RadicleCloud.Contract.ChangeOwner(&_RadicleCloud.TransactOpts, newOwner)
+}
+
+// ChangeOwner is a paid mutator transaction binding the contract method 0xa6f9dae1.
+//
+// Solidity: function changeOwner(address newOwner) returns()
+func (_RadicleCloud *RadicleCloudTransactorSession) ChangeOwner(newOwner common.Address) (*types.Transaction, error) {
+	return _RadicleCloud.Contract.ChangeOwner(&_RadicleCloud.TransactOpts, newOwner)
+}
+
+// ChangeRate is a paid mutator transaction binding the contract method 0x308961a7.
+//
+// Solidity: function changeRate(uint64 newRate) returns()
+func (_RadicleCloud *RadicleCloudTransactor) ChangeRate(opts *bind.TransactOpts, newRate uint64) (*types.Transaction, error) {
+	return _RadicleCloud.contract.Transact(opts, "changeRate", newRate)
+}
+
+// ChangeRate is a paid mutator transaction binding the contract method 0x308961a7.
+//
+// Solidity: function changeRate(uint64 newRate) returns()
+func (_RadicleCloud *RadicleCloudSession) ChangeRate(newRate uint64) (*types.Transaction, error) {
+	return _RadicleCloud.Contract.ChangeRate(&_RadicleCloud.TransactOpts, newRate)
+}
+
+// ChangeRate is a paid mutator transaction binding the contract method 0x308961a7.
+//
+// Solidity: function changeRate(uint64 newRate) returns()
+func (_RadicleCloud *RadicleCloudTransactorSession) ChangeRate(newRate uint64) (*types.Transaction, error) {
+	return _RadicleCloud.Contract.ChangeRate(&_RadicleCloud.TransactOpts, newRate)
+}
+
+// SuspendDeployment is a paid mutator transaction binding the contract method 0x83357bcd.
+//
+// Solidity: function suspendDeployment(address org) returns()
+func (_RadicleCloud *RadicleCloudTransactor) SuspendDeployment(opts *bind.TransactOpts, org common.Address) (*types.Transaction, error) {
+	return _RadicleCloud.contract.Transact(opts, "suspendDeployment", org)
+}
+
+// SuspendDeployment is a paid mutator transaction binding the contract method 0x83357bcd.
+//
+// Solidity: function suspendDeployment(address org) returns()
+func (_RadicleCloud *RadicleCloudSession) SuspendDeployment(org common.Address) (*types.Transaction, error) {
+	return _RadicleCloud.Contract.SuspendDeployment(&_RadicleCloud.TransactOpts, org)
+}
+
+// SuspendDeployment is a paid mutator transaction binding the contract method 0x83357bcd.
+//
+// Solidity: function suspendDeployment(address org) returns()
+func (_RadicleCloud *RadicleCloudTransactorSession) SuspendDeployment(org common.Address) (*types.Transaction, error) {
+	return _RadicleCloud.Contract.SuspendDeployment(&_RadicleCloud.TransactOpts, org)
+}
+
+// WithdrawRealizedRevenue is a paid mutator transaction binding the contract method 0x2ddfab15.
+//
+// Solidity: function withdrawRealizedRevenue() returns(uint128 amount)
+func (_RadicleCloud *RadicleCloudTransactor) WithdrawRealizedRevenue(opts *bind.TransactOpts) (*types.Transaction, error) {
+	return _RadicleCloud.contract.Transact(opts, "withdrawRealizedRevenue")
+}
+
+// WithdrawRealizedRevenue is a paid mutator transaction binding the contract method 0x2ddfab15.
+//
+// Solidity: function withdrawRealizedRevenue() returns(uint128 amount)
+func (_RadicleCloud *RadicleCloudSession) WithdrawRealizedRevenue() (*types.Transaction, error) {
+	return _RadicleCloud.Contract.WithdrawRealizedRevenue(&_RadicleCloud.TransactOpts)
+}
+
+// WithdrawRealizedRevenue is a paid mutator transaction binding the contract method 0x2ddfab15.
+//
+// Solidity: function withdrawRealizedRevenue() returns(uint128 amount)
+func (_RadicleCloud *RadicleCloudTransactorSession) WithdrawRealizedRevenue() (*types.Transaction, error) {
+	return _RadicleCloud.Contract.WithdrawRealizedRevenue(&_RadicleCloud.TransactOpts)
+}
+
+// RadicleCloudDeploymentOwnerChangedIterator is returned from FilterDeploymentOwnerChanged and is used to iterate over the raw logs and unpacked data for DeploymentOwnerChanged events raised by the RadicleCloud contract.
+type RadicleCloudDeploymentOwnerChangedIterator struct {
+	Event *RadicleCloudDeploymentOwnerChanged // Event containing the contract specifics and raw log
+
+	contract *bind.BoundContract // Generic contract to use for unpacking event data
+	event    string              // Event name to use for unpacking event data
+
+	logs chan types.Log        // Log channel receiving the found contract events
+	sub  ethereum.Subscription // Subscription for errors, completion and termination
+	done bool                  // Whether the subscription completed delivering logs
+	fail error                 // Occurred error to stop iteration
+}
+
+// Next advances the iterator to the subsequent event, returning whether there
+// are any more events found. In case of a retrieval or parsing error, false is
+// returned and Error() can be queried for the exact failure.
+func (it *RadicleCloudDeploymentOwnerChangedIterator) Next() bool {
+	// If the iterator failed, stop iterating
+	if it.fail != nil {
+		return false
+	}
+	// If the iterator completed, deliver directly whatever's available
+	if it.done {
+		select {
+		case log := <-it.logs:
+			it.Event = new(RadicleCloudDeploymentOwnerChanged)
+			if err := it.contract.UnpackLog(it.Event, it.event, log); err != nil {
+				it.fail = err
+				return false
+			}
+			it.Event.Raw = log
+			return true
+
+		default:
+			return false
+		}
+	}
+	// Iterator still in progress, wait for either a data or an error event
+	select {
+	case log := <-it.logs:
+		it.Event = new(RadicleCloudDeploymentOwnerChanged)
+		if err := it.contract.UnpackLog(it.Event, it.event, log); err != nil {
+			it.fail = err
+			return false
+		}
+		it.Event.Raw = log
+		return true
+
+	case err := <-it.sub.Err():
+		it.done = true
+		it.fail = err
+		return it.Next()
+	}
+}
+
+// Error returns any retrieval or parsing error occurred during filtering.
+func (it *RadicleCloudDeploymentOwnerChangedIterator) Error() error {
+	return it.fail
+}
+
+// Close terminates the iteration process, releasing any pending underlying
+// resources.
+func (it *RadicleCloudDeploymentOwnerChangedIterator) Close() error {
+	it.sub.Unsubscribe()
+	return nil
+}
+
+// RadicleCloudDeploymentOwnerChanged represents a DeploymentOwnerChanged event raised by the RadicleCloud contract.
+type RadicleCloudDeploymentOwnerChanged struct {
+	Org   common.Address
+	Owner common.Address
+	Raw   types.Log // Blockchain specific contextual infos
+}
+
+// FilterDeploymentOwnerChanged is a free log retrieval operation binding the contract event 0x84799e9e96f91f25bb30d3aa254c66097c6ca6a9bad881e7965122c81feda6de.
+//
+// Solidity: event DeploymentOwnerChanged(address org, address owner)
+func (_RadicleCloud *RadicleCloudFilterer) FilterDeploymentOwnerChanged(opts *bind.FilterOpts) (*RadicleCloudDeploymentOwnerChangedIterator, error) {
+
+	logs, sub, err := _RadicleCloud.contract.FilterLogs(opts, "DeploymentOwnerChanged")
+	if err != nil {
+		return nil, err
+	}
+	return &RadicleCloudDeploymentOwnerChangedIterator{contract: _RadicleCloud.contract, event: "DeploymentOwnerChanged", logs: logs, sub: sub}, nil
+}
+
+// WatchDeploymentOwnerChanged is a free log subscription operation binding the contract event 0x84799e9e96f91f25bb30d3aa254c66097c6ca6a9bad881e7965122c81feda6de.
+//
+// Solidity: event DeploymentOwnerChanged(address org, address owner)
+func (_RadicleCloud *RadicleCloudFilterer) WatchDeploymentOwnerChanged(opts *bind.WatchOpts, sink chan<- *RadicleCloudDeploymentOwnerChanged) (event.Subscription, error) {
+
+	logs, sub, err := _RadicleCloud.contract.WatchLogs(opts, "DeploymentOwnerChanged")
+	if err != nil {
+		return nil, err
+	}
+	return event.NewSubscription(func(quit <-chan struct{}) error {
+		defer sub.Unsubscribe()
+		for {
+			select {
+			case log := <-logs:
+				// New log arrived, parse the event and forward to the user
+				event := new(RadicleCloudDeploymentOwnerChanged)
+				if err := _RadicleCloud.contract.UnpackLog(event, "DeploymentOwnerChanged", log); err != nil {
+					return err
+				}
+				event.Raw = log
+
+				select {
+				case sink <- event:
+				case err := <-sub.Err():
+					return err
+				case <-quit:
+					return nil
+				}
+			case err := <-sub.Err():
+				return err
+			case <-quit:
+				return nil
+			}
+		}
+	}), nil
+}
+
+// ParseDeploymentOwnerChanged is a log parse operation binding the contract event 0x84799e9e96f91f25bb30d3aa254c66097c6ca6a9bad881e7965122c81feda6de.
+//
+// Solidity: event DeploymentOwnerChanged(address org, address owner)
+func (_RadicleCloud *RadicleCloudFilterer) ParseDeploymentOwnerChanged(log types.Log) (*RadicleCloudDeploymentOwnerChanged, error) {
+	event := new(RadicleCloudDeploymentOwnerChanged)
+	if err := _RadicleCloud.contract.UnpackLog(event, "DeploymentOwnerChanged", log); err != nil {
+		return nil, err
+	}
+	event.Raw = log
+	return event, nil
+}
+
+// RadicleCloudDeploymentStoppedIterator is returned from FilterDeploymentStopped and is used to iterate over the raw logs and unpacked data for DeploymentStopped events raised by the RadicleCloud contract.
+type RadicleCloudDeploymentStoppedIterator struct {
+	Event *RadicleCloudDeploymentStopped // Event containing the contract specifics and raw log
+
+	contract *bind.BoundContract // Generic contract to use for unpacking event data
+	event    string              // Event name to use for unpacking event data
+
+	logs chan types.Log        // Log channel receiving the found contract events
+	sub  ethereum.Subscription // Subscription for errors, completion and termination
+	done bool                  // Whether the subscription completed delivering logs
+	fail error                 // Occurred error to stop iteration
+}
+
+// Next advances the iterator to the subsequent event, returning whether there
+// are any more events found. In case of a retrieval or parsing error, false is
+// returned and Error() can be queried for the exact failure.
+func (it *RadicleCloudDeploymentStoppedIterator) Next() bool {
+	// If the iterator failed, stop iterating
+	if it.fail != nil {
+		return false
+	}
+	// If the iterator completed, deliver directly whatever's available
+	if it.done {
+		select {
+		case log := <-it.logs:
+			it.Event = new(RadicleCloudDeploymentStopped)
+			if err := it.contract.UnpackLog(it.Event, it.event, log); err != nil {
+				it.fail = err
+				return false
+			}
+			it.Event.Raw = log
+			return true
+
+		default:
+			return false
+		}
+	}
+	// Iterator still in progress, wait for either a data or an error event
+	select {
+	case log := <-it.logs:
+		it.Event = new(RadicleCloudDeploymentStopped)
+		if err := it.contract.UnpackLog(it.Event, it.event, log); err != nil {
+			it.fail = err
+			return false
+		}
+		it.Event.Raw = log
+		return true
+
+	case err := <-it.sub.Err():
+		it.done = true
+		it.fail = err
+		return it.Next()
+	}
+}
+
+// Error returns any retrieval or parsing error occurred during filtering.
+func (it *RadicleCloudDeploymentStoppedIterator) Error() error {
+	return it.fail
+}
+
+// Close terminates the iteration process, releasing any pending underlying
+// resources.
+func (it *RadicleCloudDeploymentStoppedIterator) Close() error {
+	it.sub.Unsubscribe()
+	return nil
+}
+
+// RadicleCloudDeploymentStopped represents a DeploymentStopped event raised by the RadicleCloud contract.
+type RadicleCloudDeploymentStopped struct {
+	Org    common.Address
+	Expiry uint64
+	Raw    types.Log // Blockchain specific contextual infos
+}
+
+// FilterDeploymentStopped is a free log retrieval operation binding the contract event 0x21e286afa12182bf295f09ac1f2162de951e250ae4a8d01a59f863d1f5687452.
+//
+// Solidity: event DeploymentStopped(address org, uint64 expiry)
+func (_RadicleCloud *RadicleCloudFilterer) FilterDeploymentStopped(opts *bind.FilterOpts) (*RadicleCloudDeploymentStoppedIterator, error) {
+
+	logs, sub, err := _RadicleCloud.contract.FilterLogs(opts, "DeploymentStopped")
+	if err != nil {
+		return nil, err
+	}
+	return &RadicleCloudDeploymentStoppedIterator{contract: _RadicleCloud.contract, event: "DeploymentStopped", logs: logs, sub: sub}, nil
+}
+
+// WatchDeploymentStopped is a free log subscription operation binding the contract event 0x21e286afa12182bf295f09ac1f2162de951e250ae4a8d01a59f863d1f5687452.
+//
+// Solidity: event DeploymentStopped(address org, uint64 expiry)
+func (_RadicleCloud *RadicleCloudFilterer) WatchDeploymentStopped(opts *bind.WatchOpts, sink chan<- *RadicleCloudDeploymentStopped) (event.Subscription, error) {
+
+	logs, sub, err := _RadicleCloud.contract.WatchLogs(opts, "DeploymentStopped")
+	if err != nil {
+		return nil, err
+	}
+	return event.NewSubscription(func(quit <-chan struct{}) error {
+		defer sub.Unsubscribe()
+		for {
+			select {
+			case log := <-logs:
+				// New log arrived, parse the event and forward to the user
+				event := new(RadicleCloudDeploymentStopped)
+				if err := _RadicleCloud.contract.UnpackLog(event, "DeploymentStopped", log); err != nil {
+					return err
+				}
+				event.Raw = log
+
+				select {
+				case sink <- event:
+				case err := <-sub.Err():
+					return err
+				case <-quit:
+					return nil
+				}
+			case err := <-sub.Err():
+				return err
+			case <-quit:
+				return nil
+			}
+		}
+	}), nil
+}
+
+// ParseDeploymentStopped is a log parse operation binding the contract event 0x21e286afa12182bf295f09ac1f2162de951e250ae4a8d01a59f863d1f5687452.
+//
+// Solidity: event DeploymentStopped(address org, uint64 expiry)
+func (_RadicleCloud *RadicleCloudFilterer) ParseDeploymentStopped(log types.Log) (*RadicleCloudDeploymentStopped, error) {
+	event := new(RadicleCloudDeploymentStopped)
+	if err := _RadicleCloud.contract.UnpackLog(event, "DeploymentStopped", log); err != nil {
+		return nil, err
+	}
+	event.Raw = log
+	return event, nil
+}
+
+// RadicleCloudNewTopUpIterator is returned from FilterNewTopUp and is used to iterate over the raw logs and unpacked data for NewTopUp events raised by the RadicleCloud contract.
+type RadicleCloudNewTopUpIterator struct {
+	Event *RadicleCloudNewTopUp // Event containing the contract specifics and raw log
+
+	contract *bind.BoundContract // Generic contract to use for unpacking event data
+	event    string              // Event name to use for unpacking event data
+
+	logs chan types.Log        // Log channel receiving the found contract events
+	sub  ethereum.Subscription // Subscription for errors, completion and termination
+	done bool                  // Whether the subscription completed delivering logs
+	fail error                 // Occurred error to stop iteration
+}
+
+// Next advances the iterator to the subsequent event, returning whether there
+// are any more events found. In case of a retrieval or parsing error, false is
+// returned and Error() can be queried for the exact failure.
+func (it *RadicleCloudNewTopUpIterator) Next() bool {
+	// If the iterator failed, stop iterating
+	if it.fail != nil {
+		return false
+	}
+	// If the iterator completed, deliver directly whatever's available
+	if it.done {
+		select {
+		case log := <-it.logs:
+			it.Event = new(RadicleCloudNewTopUp)
+			if err := it.contract.UnpackLog(it.Event, it.event, log); err != nil {
+				it.fail = err
+				return false
+			}
+			it.Event.Raw = log
+			return true
+
+		default:
+			return false
+		}
+	}
+	// Iterator still in progress, wait for either a data or an error event
+	select {
+	case log := <-it.logs:
+		it.Event = new(RadicleCloudNewTopUp)
+		if err := it.contract.UnpackLog(it.Event, it.event, log); err != nil {
+			it.fail = err
+			return false
+		}
+		it.Event.Raw = log
+		return true
+
+	case err := <-it.sub.Err():
+		it.done = true
+		it.fail = err
+		return it.Next()
+	}
+}
+
+// Error returns any retrieval or parsing error occurred during filtering.
+func (it *RadicleCloudNewTopUpIterator) Error() error {
+	return it.fail
+}
+
+// Close terminates the iteration process, releasing any pending underlying
+// resources.
+func (it *RadicleCloudNewTopUpIterator) Close() error {
+	it.sub.Unsubscribe()
+	return nil
+}
+
+// RadicleCloudNewTopUp represents a NewTopUp event raised by the RadicleCloud contract.
+type RadicleCloudNewTopUp struct {
+	Org    common.Address
+	Expiry uint64
+	Raw    types.Log // Blockchain specific contextual infos
+}
+
+// FilterNewTopUp is a free log retrieval operation binding the contract event 0x12a4b3f22887e758e0c59636a272daff04fd132b80da61f75fa59f6e7d494c53.
+//
+// Solidity: event NewTopUp(address org, uint64 expiry)
+func (_RadicleCloud *RadicleCloudFilterer) FilterNewTopUp(opts *bind.FilterOpts) (*RadicleCloudNewTopUpIterator, error) {
+
+	logs, sub, err := _RadicleCloud.contract.FilterLogs(opts, "NewTopUp")
+	if err != nil {
+		return nil, err
+	}
+	return &RadicleCloudNewTopUpIterator{contract: _RadicleCloud.contract, event: "NewTopUp", logs: logs, sub: sub}, nil
+}
+
+// WatchNewTopUp is a free log subscription operation binding the contract event 0x12a4b3f22887e758e0c59636a272daff04fd132b80da61f75fa59f6e7d494c53.
+//
+// Solidity: event NewTopUp(address org, uint64 expiry)
+func (_RadicleCloud *RadicleCloudFilterer) WatchNewTopUp(opts *bind.WatchOpts, sink chan<- *RadicleCloudNewTopUp) (event.Subscription, error) {
+
+	logs, sub, err := _RadicleCloud.contract.WatchLogs(opts, "NewTopUp")
+	if err != nil {
+		return nil, err
+	}
+	return event.NewSubscription(func(quit <-chan struct{}) error {
+		defer sub.Unsubscribe()
+		for {
+			select {
+			case log := <-logs:
+				// New log arrived, parse the event and forward to the user
+				event := new(RadicleCloudNewTopUp)
+				if err := _RadicleCloud.contract.UnpackLog(event, "NewTopUp", log); err != nil {
+					return err
+				}
+				event.Raw = log
+
+				select {
+				case sink <- event:
+				case err := <-sub.Err():
+					return err
+				case <-quit:
+					return nil
+				}
+			case err := <-sub.Err():
+				return err
+			case <-quit:
+				return nil
+			}
+		}
+	}), nil
+}
+
+// ParseNewTopUp is a log parse operation binding the contract event 0x12a4b3f22887e758e0c59636a272daff04fd132b80da61f75fa59f6e7d494c53.
+//
+// Solidity: event NewTopUp(address org, uint64 expiry)
+func (_RadicleCloud *RadicleCloudFilterer) ParseNewTopUp(log types.Log) (*RadicleCloudNewTopUp, error) {
+	event := new(RadicleCloudNewTopUp)
+	if err := _RadicleCloud.contract.UnpackLog(event, "NewTopUp", log); err != nil {
+		return nil, err
+	}
+	event.Raw = log
+	return event, nil
+}
+
+// RadicleCloudOwnerChangedIterator is returned from FilterOwnerChanged and is used to iterate over the raw logs and unpacked data for OwnerChanged events raised by the RadicleCloud contract.
+type RadicleCloudOwnerChangedIterator struct {
+	Event *RadicleCloudOwnerChanged // Event containing the contract specifics and raw log
+
+	contract *bind.BoundContract // Generic contract to use for unpacking event data
+	event    string              // Event name to use for unpacking event data
+
+	logs chan types.Log        // Log channel receiving the found contract events
+	sub  ethereum.Subscription // Subscription for errors, completion and termination
+	done bool                  // Whether the subscription completed delivering logs
+	fail error                 // Occurred error to stop iteration
+}
+
+// Next advances the iterator to the subsequent event, returning whether there
+// are any more events found. In case of a retrieval or parsing error, false is
+// returned and Error() can be queried for the exact failure.
+func (it *RadicleCloudOwnerChangedIterator) Next() bool {
+	// If the iterator failed, stop iterating
+	if it.fail != nil {
+		return false
+	}
+	// If the iterator completed, deliver directly whatever's available
+	if it.done {
+		select {
+		case log := <-it.logs:
+			it.Event = new(RadicleCloudOwnerChanged)
+			if err := it.contract.UnpackLog(it.Event, it.event, log); err != nil {
+				it.fail = err
+				return false
+			}
+			it.Event.Raw = log
+			return true
+
+		default:
+			return false
+		}
+	}
+	// Iterator still in progress, wait for either a data or an error event
+	select {
+	case log := <-it.logs:
+		it.Event = new(RadicleCloudOwnerChanged)
+		if err := it.contract.UnpackLog(it.Event, it.event, log); err != nil {
+			it.fail = err
+			return false
+		}
+		it.Event.Raw = log
+		return true
+
+	case err := <-it.sub.Err():
+		it.done = true
+		it.fail = err
+		return it.Next()
+	}
+}
+
+// Error returns any retrieval or parsing error occurred during filtering.
+func (it *RadicleCloudOwnerChangedIterator) Error() error {
+	return it.fail
+}
+
+// Close terminates the iteration process, releasing any pending underlying
+// resources.
+func (it *RadicleCloudOwnerChangedIterator) Close() error {
+	it.sub.Unsubscribe()
+	return nil
+}
+
+// RadicleCloudOwnerChanged represents a OwnerChanged event raised by the RadicleCloud contract.
+type RadicleCloudOwnerChanged struct {
+	Owner common.Address
+	Raw   types.Log // Blockchain specific contextual infos
+}
+
+// FilterOwnerChanged is a free log retrieval operation binding the contract event 0xa2ea9883a321a3e97b8266c2b078bfeec6d50c711ed71f874a90d500ae2eaf36.
+//
+// Solidity: event OwnerChanged(address owner)
+func (_RadicleCloud *RadicleCloudFilterer) FilterOwnerChanged(opts *bind.FilterOpts) (*RadicleCloudOwnerChangedIterator, error) {
+
+	logs, sub, err := _RadicleCloud.contract.FilterLogs(opts, "OwnerChanged")
+	if err != nil {
+		return nil, err
+	}
+	return &RadicleCloudOwnerChangedIterator{contract: _RadicleCloud.contract, event: "OwnerChanged", logs: logs, sub: sub}, nil
+}
+
+// WatchOwnerChanged is a free log subscription operation binding the contract event 0xa2ea9883a321a3e97b8266c2b078bfeec6d50c711ed71f874a90d500ae2eaf36.
+//
+// Solidity: event OwnerChanged(address owner)
+func (_RadicleCloud *RadicleCloudFilterer) WatchOwnerChanged(opts *bind.WatchOpts, sink chan<- *RadicleCloudOwnerChanged) (event.Subscription, error) {
+
+	logs, sub, err := _RadicleCloud.contract.WatchLogs(opts, "OwnerChanged")
+	if err != nil {
+		return nil, err
+	}
+	return event.NewSubscription(func(quit <-chan struct{}) error {
+		defer sub.Unsubscribe()
+		for {
+			select {
+			case log := <-logs:
+				// New log arrived, parse the event and forward to the user
+				event := new(RadicleCloudOwnerChanged)
+				if err := _RadicleCloud.contract.UnpackLog(event, "OwnerChanged", log); err != nil {
+					return err
+				}
+				event.Raw = log
+
+				select {
+				case sink <- event:
+				case err := <-sub.Err():
+					return err
+				case <-quit:
+					return nil
+				}
+			case err := <-sub.Err():
+				return err
+			case <-quit:
+				return nil
+			}
+		}
+	}), nil
+}
+
+// ParseOwnerChanged is a log parse operation binding the contract event 0xa2ea9883a321a3e97b8266c2b078bfeec6d50c711ed71f874a90d500ae2eaf36.
+//
+// Solidity: event OwnerChanged(address owner)
+func (_RadicleCloud *RadicleCloudFilterer) ParseOwnerChanged(log types.Log) (*RadicleCloudOwnerChanged, error) {
+	event := new(RadicleCloudOwnerChanged)
+	if err := _RadicleCloud.contract.UnpackLog(event, "OwnerChanged", log); err != nil {
+		return nil, err
+	}
+	event.Raw = log
+	return event, nil
+}
+
+// RadicleCloudRateChangedIterator is returned from FilterRateChanged and is used to iterate over the raw logs and unpacked data for RateChanged events raised by the RadicleCloud contract.
+type RadicleCloudRateChangedIterator struct {
+	Event *RadicleCloudRateChanged // Event containing the contract specifics and raw log
+
+	contract *bind.BoundContract // Generic contract to use for unpacking event data
+	event    string              // Event name to use for unpacking event data
+
+	logs chan types.Log        // Log channel receiving the found contract events
+	sub  ethereum.Subscription // Subscription for errors, completion and termination
+	done bool                  // Whether the subscription completed delivering logs
+	fail error                 // Occurred error to stop iteration
+}
+
+// Next advances the iterator to the subsequent event, returning whether there
+// are any more events found. In case of a retrieval or parsing error, false is
+// returned and Error() can be queried for the exact failure.
+func (it *RadicleCloudRateChangedIterator) Next() bool {
+	// If the iterator failed, stop iterating
+	if it.fail != nil {
+		return false
+	}
+	// If the iterator completed, deliver directly whatever's available
+	if it.done {
+		select {
+		case log := <-it.logs:
+			it.Event = new(RadicleCloudRateChanged)
+			if err := it.contract.UnpackLog(it.Event, it.event, log); err != nil {
+				it.fail = err
+				return false
+			}
+			it.Event.Raw = log
+			return true
+
+		default:
+			return false
+		}
+	}
+	// Iterator still in progress, wait for either a data or an error event
+	select {
+	case log := <-it.logs:
+		it.Event = new(RadicleCloudRateChanged)
+		if err := it.contract.UnpackLog(it.Event, it.event, log); err != nil {
+			it.fail = err
+			return false
+		}
+		it.Event.Raw = log
+		return true
+
+	case err := <-it.sub.Err():
+		it.done = true
+		it.fail = err
+		return it.Next()
+	}
+}
+
+// Error returns any retrieval or parsing error occurred during filtering.
+func (it *RadicleCloudRateChangedIterator) Error() error {
+	return it.fail
+}
+
+// Close terminates the iteration process, releasing any pending underlying
+// resources.
+func (it *RadicleCloudRateChangedIterator) Close() error {
+	it.sub.Unsubscribe()
+	return nil
+}
+
+// RadicleCloudRateChanged represents a RateChanged event raised by the RadicleCloud contract.
+type RadicleCloudRateChanged struct {
+	Rate uint64
+	Raw  types.Log // Blockchain specific contextual infos
+}
+
+// FilterRateChanged is a free log retrieval operation binding the contract event 0x3d20779d2b1a577c5438c4319e9ebf6be363899186a6e7c9f42d8bdac2e5f728.
+//
+// Solidity: event RateChanged(uint64 rate)
+func (_RadicleCloud *RadicleCloudFilterer) FilterRateChanged(opts *bind.FilterOpts) (*RadicleCloudRateChangedIterator, error) {
+
+	logs, sub, err := _RadicleCloud.contract.FilterLogs(opts, "RateChanged")
+	if err != nil {
+		return nil, err
+	}
+	return &RadicleCloudRateChangedIterator{contract: _RadicleCloud.contract, event: "RateChanged", logs: logs, sub: sub}, nil
+}
+
+// WatchRateChanged is a free log subscription operation binding the contract event 0x3d20779d2b1a577c5438c4319e9ebf6be363899186a6e7c9f42d8bdac2e5f728.
+//
+// Solidity: event RateChanged(uint64 rate)
+func (_RadicleCloud *RadicleCloudFilterer) WatchRateChanged(opts *bind.WatchOpts, sink chan<- *RadicleCloudRateChanged) (event.Subscription, error) {
+
+	logs, sub, err := _RadicleCloud.contract.WatchLogs(opts, "RateChanged")
+	if err != nil {
+		return nil, err
+	}
+	return event.NewSubscription(func(quit <-chan struct{}) error {
+		defer sub.Unsubscribe()
+		for {
+			select {
+			case log := <-logs:
+				// New log arrived, parse the event and forward to the user
+				event := new(RadicleCloudRateChanged)
+				if err := _RadicleCloud.contract.UnpackLog(event, "RateChanged", log); err != nil {
+					return err
+				}
+				event.Raw = log
+
+				select {
+				case sink <- event:
+				case err := <-sub.Err():
+					return err
+				case <-quit:
+					return nil
+				}
+			case err := <-sub.Err():
+				return err
+			case <-quit:
+				return nil
+			}
+		}
+	}), nil
+}
+
+// ParseRateChanged is a log parse operation binding the contract event 0x3d20779d2b1a577c5438c4319e9ebf6be363899186a6e7c9f42d8bdac2e5f728.
+//
+// Solidity: event RateChanged(uint64 rate)
+func (_RadicleCloud *RadicleCloudFilterer) ParseRateChanged(log types.Log) (*RadicleCloudRateChanged, error) {
+	event := new(RadicleCloudRateChanged)
+	if err := _RadicleCloud.contract.UnpackLog(event, "RateChanged", log); err != nil {
+		return nil, err
+	}
+	event.Raw = log
+	return event, nil
+}
@@ -0,0 +1,85 @@
+// SPDX-License-Identifier: Apache-2.0
+
+package eth
+
+import (
+	"context"
+	"math/big"
+
+	"github.com/ethereum/go-ethereum/common"
+)
+
+// liveCheckpointBlocks is how many blocks the cursor trails behind and moves
+// at once while following the chain live
+const liveCheckpointBlocks = 64
+
+// Cursor is the last block whose logs were all emitted, Hash is that of the
+// block and empty when it isn't known
+type Cursor struct {
+	Block uint64
+	Hash  common.Hash
+}
+
+// resume returns the block to listen from after c. If c's block was orphaned
+// while nobody was listening, the blocks before it are read again as far back
+// as orphaned logs are held.
+func (c Cursor) resume(ctx context.Context, client ChainClient, source Source) (uint64, error) {
+	from := c.Block + 1
+	if c.Hash != (common.Hash{}) {
+		header, err := client.HeaderByNumber(ctx, new(big.Int).SetUint64(c.Block))
+		if err != nil {
+			return 0, err
+		}
+		if header.Hash() != c.Hash {
+			l.Printf("Block %d of cursor of %s was orphaned, reading %d blocks before it again\n", c.Block, source, staleBlocks)
+			if from > staleBlocks {
+				from -= staleBlocks
+			} else {
+				from = 0
+			}
+		}
+	}
+	if from < source.StartBlock {
+		from = source.StartBlock
+	}
+	return from, nil
+}
+
+// cursorAt returns the cursor at block end, or nil if it doesn't move past
+// the last one. Logs still waiting for confirmations hold it back since
+// they're read again after a restart.
+func (li *listener) cursorAt(ctx context.Context, client ChainClient, end uint64, cf *confirmer) *Cursor {
+	if oldest, ok := cf.oldest(); ok && oldest <= end {
+		if oldest == 0 {
+			return nil
+		}
+		end = oldest - 1
+	}
+	if end <= li.checkpointed {
+		return nil
+	}
+	header, err := client.HeaderByNumber(ctx, new(big.Int).SetUint64(end))
+	if err != nil {
+		l.Printf("Failed to get block %d for cursor of %s: %v\n", end, li.source, err)
+		return nil
+	}
+	li.checkpointed = end
+	return &Cursor{Block: end, Hash: header.Hash()}
+}
+
+// flush emits events in order with cursor attached to the last one, so that
+// both are stored at once, or on its own if there are no events
+func (li *listener) flush(ctx context.Context, events []Event, cursor *Cursor) bool {
+	if cursor != nil {
+		if len(events) == 0 {
+			events = append(events, Event{BlockNumber: cursor.Block, Type: CheckpointEvent})
+		}
+		events[len(events)-1].Cursor = cursor
+	}
+	for _, e := range events {
+		if !li.emit(ctx, e) {
+			return false
+		}
+	}
+	return true
+}
@@ -0,0 +1,98 @@
+// SPDX-License-Identifier: Apache-2.0
+
+package eth
+
+import (
+	"os"
+	"strconv"
+
+	"github.com/ethereum/go-ethereum/common"
+)
+
+// headRingSize is how many recent heads are kept to detect reorgs
+const headRingSize = 64
+
+// Reorg is sent when a new head orphaned recent heads, Depth is how many L1
+// blocks went back and Number is the current L1 block after it
+type Reorg struct {
+	Depth  uint64
+	Number uint64
+}
+
+// l1ReorgDepth returns how many L1 blocks a head at L1 block n took back from
+// the last one. On L1 that's the orphaned heads of the ring, L2 heads are
+// only followed for their l1BlockNumber, which goes back on an L1 reorg
+// whether or not L2 blocks were orphaned.
+func l1ReorgDepth(orphaned uint64, fromL2 bool, last uint64, n uint64) uint64 {
+	if !fromL2 {
+		return orphaned
+	}
+	if n < last {
+		return last - n
+	}
+	return 0
+}
+
+// ExpiryFinality returns how many blocks past its expiry a deployment is
+// terminated, so that a reorg can't end it early. It's read from
+// EXPIRY_FINALITY_BLOCKS and defaults to 2.
+func ExpiryFinality() uint64 {
+	v := os.Getenv("EXPIRY_FINALITY_BLOCKS")
+	if v == "" {
+		return 2
+	}
+	blocks, err := strconv.ParseUint(v, 10, 64)
+	if err != nil {
+		l.Fatal("Invalid EXPIRY_FINALITY_BLOCKS ", v)
+	}
+	return blocks
+}
+
+type ringHead struct {
+	number uint64
+	hash   common.Hash
+}
+
+// headRing holds the number and hash of recent heads in ascending order
+type headRing struct {
+	heads []ringHead
+}
+
+// extends reports whether the head with parent follows the latest one
+func (r *headRing) extends(number uint64, parent common.Hash) bool {
+	if len(r.heads) == 0 {
+		return true
+	}
+	last := r.heads[len(r.heads)-1]
+	return last.number+1 == number && last.hash == parent
+}
+
+// push records a head, replacing those at or above its number
+func (r *headRing) push(number uint64, hash common.Hash) {
+	for len(r.heads) > 0 && r.heads[len(r.heads)-1].number >= number {
+		r.heads = r.heads[:len(r.heads)-1]
+	}
+	r.heads = append(r.heads, ringHead{number, hash})
+	if len(r.heads) > headRingSize {
+		r.heads = r.heads[len(r.heads)-headRingSize:]
+	}
+}
+
+// rewind drops the latest heads which aren't canonical anymore and returns
+// how many, canonical returns the hash of a block of the chain now
+func (r *headRing) rewind(canonical func(number uint64) (common.Hash, error)) (uint64, error) {
+	depth := uint64(0)
+	for len(r.heads) > 0 {
+		last := r.heads[len(r.heads)-1]
+		hash, err := canonical(last.number)
+		if err != nil {
+			return depth, err
+		}
+		if hash == last.hash {
+			break
+		}
+		r.heads = r.heads[:len(r.heads)-1]
+		depth++
+	}
+	return depth, nil
+}
@@ -0,0 +1,69 @@
+// SPDX-License-Identifier: Apache-2.0
+
+package eth
+
+import (
+	"testing"
+
+	"github.com/ethereum/go-ethereum/common"
+)
+
+func TestHeadRing(t *testing.T) {
+	hash := func(fork byte, number uint64) common.Hash {
+		return common.BytesToHash([]byte{fork, byte(number)})
+	}
+	r := &headRing{}
+	for n := uint64(1); n <= 5; n++ {
+		if !r.extends(n, hash('a', n-1)) {
+			t.Fatalf("Expected: block %d to extend the ring", n)
+		}
+		r.push(n, hash('a', n))
+	}
+
+	// fork b branches off after block 3
+	if r.extends(6, hash('b', 5)) {
+		t.Fatal("Expected: a different parent not to extend the ring")
+	}
+	canonical := func(n uint64) (common.Hash, error) {
+		if n <= 3 {
+			return hash('a', n), nil
+		}
+		return hash('b', n), nil
+	}
+	depth, err := r.rewind(canonical)
+	if err != nil || depth != 2 {
+		t.Fatalf("Expected: 2 orphaned blocks, Actual: %d %v", depth, err)
+	}
+	r.push(6, hash('b', 6))
+	if len(r.heads) != 4 || r.heads[2].number != 3 || r.heads[3].number != 6 {
+		t.Fatalf("Unexpected heads %+v", r.heads)
+	}
+
+	// a gap without a reorg orphans nothing
+	if depth, err = r.rewind(canonical); err != nil || depth != 0 {
+		t.Fatalf("Expected: no orphaned blocks, Actual: %d %v", depth, err)
+	}
+
+	for n := uint64(7); n < 7+headRingSize; n++ {
+		r.push(n, hash('b', n))
+	}
+	if len(r.heads) != headRingSize {
+		t.Fatalf("Expected: %d heads, Actual: %d", headRingSize, len(r.heads))
+	}
+}
+
+func TestL1ReorgDepth(t *testing.T) {
+	if depth := l1ReorgDepth(3, false, 100, 98); depth != 3 {
+		t.Errorf("Expected: orphaned L1 heads, Actual: %d", depth)
+	}
+	// L2 heads are counted by their l1BlockNumber
+	if depth := l1ReorgDepth(5, true, 100, 100); depth != 0 {
+		t.Errorf("Expected: an L2 reorg on the same L1 block not to count, Actual: %d", depth)
+	}
+	if depth := l1ReorgDepth(0, true, 100, 97); depth != 3 {
+		t.Errorf("Expected: an L1 reorg seen through L2 heads, Actual: %d", depth)
+	}
+	if depth := l1ReorgDepth(0, true, 100, 101); depth != 0 {
+		t.Errorf("Expected: no reorg when L1 moves on, Actual: %d", depth)
+	}
+}
@@ -0,0 +1,231 @@
+// SPDX-License-Identifier: Apache-2.0
+
+package eth
+
+import (
+	"context"
+	"errors"
+	"math/big"
+	"os"
+	"radicle-cloud/metrics"
+	"sync/atomic"
+	"time"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/common/hexutil"
+	"github.com/ethereum/go-ethereum/rpc"
+)
+
+// errNoL1BlockNumber is returned when heads of an L2 node lack l1BlockNumber
+var errNoL1BlockNumber = errors.New("endpoint doesn't report l1BlockNumber")
+
+// head is the part of a block header we need, L1BlockNumber is only set by
+// Arbitrum nodes and is what block.number returns inside the contract
+type head struct {
+	Number        *hexutil.Big    `json:"number"`
+	Hash          common.Hash     `json:"hash"`
+	ParentHash    common.Hash     `json:"parentHash"`
+	Timestamp     hexutil.Uint64  `json:"timestamp"`
+	L1BlockNumber *hexutil.Uint64 `json:"l1BlockNumber"`
+}
+
+// L1BlockFromL2 reports whether the L1 block number is read from L2 heads
+// instead of connecting to CONTRACT_L1_WSS
+func L1BlockFromL2() bool {
+	return os.Getenv("L1_BLOCK_SOURCE") == "l2"
+}
+
+// UpdateCurrentBlock follows new heads to atomically update the passed integer
+// to the latest L1 block until ctx is done or the endpoint fails, every head is
+// observed by bt and its number sent to heads unless the previous one wasn't
+// received. Heads which orphan recent ones are sent to reorgs.
+func UpdateCurrentBlock(ctx context.Context, endpoints *Endpoints, current *uint64, bt *BlockTimer, heads chan uint64, reorgs chan Reorg) error {
+	client, rawurl, err := endpoints.DialRPC(ctx)
+	if err != nil {
+		return err
+	}
+	defer client.Close()
+	err = followHeads(ctx, client, isPolling(rawurl), L1BlockFromL2(), current, bt, heads, reorgs)
+	if ctx.Err() != nil {
+		return nil
+	}
+	if err != nil {
+		endpoints.Failed(rawurl)
+	}
+	return err
+}
+
+// CurrentBlock returns the latest L1 block once, the way UpdateCurrentBlock
+// follows it
+func CurrentBlock(ctx context.Context, endpoints *Endpoints) (uint64, error) {
+	client, rawurl, err := endpoints.DialRPC(ctx)
+	if err != nil {
+		return 0, err
+	}
+	defer client.Close()
+	h, err := headByNumber(ctx, client, nil)
+	if err != nil {
+		endpoints.Failed(rawurl)
+		return 0, err
+	}
+	return l1Number(h, L1BlockFromL2())
+}
+
+func followHeads(ctx context.Context, client *rpc.Client, polling bool, fromL2 bool, current *uint64, bt *BlockTimer, heads chan uint64, reorgs chan Reorg) error {
+	ring := &headRing{}
+	seen := false
+	update := func(h *head) error {
+		orphaned, err := checkReorg(ctx, client, ring, h)
+		if err != nil {
+			return err
+		}
+		n, err := l1Number(h, fromL2)
+		if err != nil {
+			return err
+		}
+		depth := uint64(0)
+		if seen {
+			depth = l1ReorgDepth(orphaned, fromL2, atomic.LoadUint64(current), n)
+		}
+		if orphaned > 0 || depth > 0 {
+			l.Printf("Reorg orphaned %d heads and %d L1 blocks, current block is at %d\n", orphaned, depth, n)
+			metrics.ChainReorgs.Inc()
+			select {
+			case reorgs <- Reorg{Depth: depth, Number: n}:
+			default:
+			}
+		}
+		// several L2 blocks share an L1 block
+		if seen && n == atomic.LoadUint64(current) {
+			return nil
+		}
+		seen = true
+		bt.Observe(n, uint64(h.Timestamp))
+		atomic.StoreUint64(current, n)
+		metrics.CurrentBlock.Set(float64(n))
+		metrics.BlockInterval.Set(bt.Interval().Seconds())
+		// replace a head the scheduler hasn't picked up yet
+		select {
+		case <-heads:
+		default:
+		}
+		heads <- n
+		return nil
+	}
+
+	h, err := headByNumber(ctx, client, nil)
+	if err != nil {
+		return err
+	}
+	// seed the estimator with an older header so it's accurate right away,
+	// on L2 the older header is found by going back as many L2 blocks
+	if latest := h.Number.ToInt().Uint64(); latest > blockTimeWindow {
+		old, err := headByNumber(ctx, client, new(big.Int).SetUint64(latest-blockTimeWindow))
+		if err != nil {
+			return err
+		}
+		n, err := l1Number(old, fromL2)
+		if err != nil {
+			return err
+		}
+		bt.Observe(n, uint64(old.Timestamp))
+	}
+	if err := update(h); err != nil {
+		return err
+	}
+	l.Printf("Current block is at %d, blocks take %s\n", atomic.LoadUint64(current), bt.Interval())
+
+	if polling {
+		ticker := time.NewTicker(PollInterval())
+		defer ticker.Stop()
+		for {
+			select {
+			case <-ctx.Done():
+				return nil
+			case <-ticker.C:
+			}
+			h, err := headByNumber(ctx, client, nil)
+			if err != nil {
+				return err
+			}
+			if err := update(h); err != nil {
+				return err
+			}
+		}
+	}
+
+	hs := make(chan *head)
+	sub, err := client.EthSubscribe(ctx, hs, "newHeads")
+	if err != nil {
+		return err
+	}
+	defer sub.Unsubscribe()
+	for {
+		select {
+		case <-ctx.Done():
+			return nil
+		case err := <-sub.Err():
+			metrics.SubscriptionErrors.Inc()
+			return err
+		case h := <-hs:
+			if err := update(h); err != nil {
+				return err
+			}
+		}
+	}
+}
+
+// checkReorg records h in ring and returns how many recent heads it orphaned
+func checkReorg(ctx context.Context, client *rpc.Client, ring *headRing, h *head) (uint64, error) {
+	number := h.Number.ToInt().Uint64()
+	orphaned := uint64(0)
+	if !ring.extends(number, h.ParentHash) {
+		var err error
+		orphaned, err = ring.rewind(func(n uint64) (common.Hash, error) {
+			// blocks past a shorter new chain are gone
+			if n >= number {
+				if n == number {
+					return h.Hash, nil
+				}
+				return common.Hash{}, nil
+			}
+			canonical, err := headByNumber(ctx, client, new(big.Int).SetUint64(n))
+			if err != nil {
+				return common.Hash{}, err
+			}
+			return canonical.Hash, nil
+		})
+		if err != nil {
+			return 0, err
+		}
+	}
+	ring.push(number, h.Hash)
+	return orphaned, nil
+}
+
+// headByNumber returns the head of block n or the latest one if n is nil
+func headByNumber(ctx context.Context, client *rpc.Client, n *big.Int) (*head, error) {
+	arg := "latest"
+	if n != nil {
+		arg = hexutil.EncodeBig(n)
+	}
+	var h *head
+	if err := client.CallContext(ctx, &h, "eth_getBlockByNumber", arg, false); err != nil {
+		return nil, err
+	}
+	if h == nil || h.Number == nil {
+		return nil, errors.New("block not found")
+	}
+	return h, nil
+}
+
+// l1Number returns the L1 block number of h
+func l1Number(h *head, fromL2 bool) (uint64, error) {
+	if !fromL2 {
+		return h.Number.ToInt().Uint64(), nil
+	}
+	if h.L1BlockNumber == nil {
+		return 0, errNoL1BlockNumber
+	}
+	return uint64(*h.L1BlockNumber), nil
+}
@@ -4,46 +4,78 @@ package eth
 
 import (
 	"context"
-	"encoding/binary"
 	"fmt"
 	"log"
 	"math/big"
 	"os"
+	"radicle-cloud/eth/contract"
+	"radicle-cloud/metrics"
+	"strconv"
+	"strings"
 	"time"
 
 	"github.com/ethereum/go-ethereum"
+	"github.com/ethereum/go-ethereum/accounts/abi/bind"
 	"github.com/ethereum/go-ethereum/common"
 	"github.com/ethereum/go-ethereum/core/types"
-	"github.com/ethereum/go-ethereum/crypto"
-	"github.com/ethereum/go-ethereum/ethclient"
 )
 
 var newTopUpHash common.Hash
 var deploymentStoppedHash common.Hash
+var rateChangedHash common.Hash
+var ownerChangedHash common.Hash
+var deploymentOwnerChangedHash common.Hash
 var l *log.Logger
-var c chan Event
 
 type EventType uint8
 
 const (
 	TopUpEvent EventType = iota
 	DeploymentStoppedEvent
+	RateChangedEvent
+	OwnerChangedEvent
+	DeploymentOwnerChangedEvent
+	// CheckpointEvent marks that all logs up to its BlockNumber were emitted
+	CheckpointEvent
 )
 
 func init() {
 	l = log.New(os.Stderr, "[ETH]	", log.Ldate|log.Ltime|log.Lshortfile)
-	newTopUpHash = crypto.Keccak256Hash([]byte("NewTopUp(address,uint64)"))
-	deploymentStoppedHash = crypto.Keccak256Hash([]byte("DeploymentStopped(address,uint64)"))
+	contractABI, err := contract.RadicleCloudMetaData.GetAbi()
+	if err != nil {
+		l.Fatal("Invalid contract ABI", err)
+	}
+	newTopUpHash = contractABI.Events["NewTopUp"].ID
+	deploymentStoppedHash = contractABI.Events["DeploymentStopped"].ID
+	rateChangedHash = contractABI.Events["RateChanged"].ID
+	ownerChangedHash = contractABI.Events["OwnerChanged"].ID
+	deploymentOwnerChangedHash = contractABI.Events["DeploymentOwnerChanged"].ID
 }
 
-// Event is what we emit to main on each purchase
+// Event is what we emit to main on each purchase or change of the contract,
+// Rate and Owner are only set by the latter
 type Event struct {
 	Org         string
 	Expiry      uint64
+	Rate        uint64
+	Owner       string
 	BlockNumber uint64
 	BlockAndTx  []byte
+	LogIndex    uint
 	Removed     bool
 	Type        EventType
+	Source      string
+	// Cursor is set when all logs up to it were emitted with this event
+	Cursor *Cursor
+}
+
+// listener decodes logs of a single source into events
+type listener struct {
+	source   Source
+	filterer *contract.RadicleCloudFilterer
+	c        chan Event
+	// checkpointed is the block of the last cursor emitted
+	checkpointed uint64
 }
 
 /*
@@ -57,110 +89,391 @@ func startListening(c chan Event, from *big.Int) {
 }
 */
 
-// StartListening listens for contract events from chain
-func StartListening(ec chan Event, from *big.Int) {
-	c = ec
-	var err error
-	client, err := ethclient.Dial(os.Getenv("CONTRACT_L2_WSS"))
+// StartListening listens for events of source's contract after cursor until
+// ctx is done or the endpoint fails, a failed endpoint is skipped when
+// listening again
+func StartListening(ctx context.Context, endpoints *Endpoints, source Source, ec chan Event, cursor Cursor) error {
+	client, rawurl, err := endpoints.Dial(ctx)
+	if err != nil {
+		return err
+	}
+	defer client.Close()
+	from, err := cursor.resume(ctx, client, source)
+	if err == nil {
+		l.Println("Listening for contract events of", source, "from block", from, "on", Redact(rawurl))
+		err = Listen(ctx, client, isPolling(rawurl), source, ec, new(big.Int).SetUint64(from))
+	}
+	if ctx.Err() != nil {
+		return nil
+	}
 	if err != nil {
-		l.Fatal(err)
+		endpoints.Failed(rawurl)
 	}
+	return err
+}
+
+// ChainClient is what listening needs from a node, it's satisfied by
+// ethclient and go-ethereum's simulated backend
+type ChainClient interface {
+	bind.ContractFilterer
+	HeaderByNumber(ctx context.Context, number *big.Int) (*types.Header, error)
+	SubscribeNewHead(ctx context.Context, ch chan<- *types.Header) (ethereum.Subscription, error)
+}
 
-	contractAddress := common.HexToAddress(os.Getenv("CONTRACT_ADDRESS"))
+// Listen emits events of source's contract from block from onwards to ec
+// until ctx is done or client fails, polling is for clients without
+// subscriptions
+func Listen(ctx context.Context, client ChainClient, polling bool, source Source, ec chan Event, from *big.Int) error {
+	filterer, err := contract.NewRadicleCloudFilterer(source.Address, client)
+	if err != nil {
+		return err
+	}
+	li := &listener{source: source, filterer: filterer, c: ec}
+	if from.Sign() > 0 {
+		li.checkpointed = from.Uint64() - 1
+	}
+	return li.listen(ctx, client, polling, from)
+}
+
+func (li *listener) listen(ctx context.Context, client ChainClient, polling bool, from *big.Int) error {
 	query := ethereum.FilterQuery{
 		FromBlock: from,
-		Addresses: []common.Address{contractAddress},
+		Addresses: []common.Address{li.source.Address},
 	}
+	pager := newLogPager(li.source, client, LogPageSize())
 
-	// handle historic events
-	history, err := client.FilterLogs(context.Background(), query)
-	if err != nil {
-		l.Fatal(err)
+	// logs are held back until they're depth blocks deep so that orphaned
+	// top-ups never reach provisioning, buffered logs are read again from
+	// chain after a restart since they were never stored
+	depth := ConfirmationDepth()
+	cf := newConfirmer(depth)
+	ingest := func(log types.Log) bool {
+		if depth == 0 || !cf.add(log) {
+			return li.handleLog(ctx, log)
+		}
+		if log.Removed {
+			l.Printf("Cancelled unconfirmed log in tx=%s\n", log.TxHash.Hex())
+		}
+		return true
 	}
-	for _, h := range history {
-		handleLog(h)
+	// refetch is the block polling has to fetch again from because logs of
+	// it were orphaned, their replacements may be in blocks polled already
+	var refetch *uint64
+	// release emits logs confirmed at head and returns false if ctx was done.
+	// Failing to check a log fails listening, the cursor is still held back by
+	// the log so it's read again after reconnecting.
+	release := func(head uint64) (bool, error) {
+		for _, log := range cf.release(head) {
+			// a reorg's new heads may arrive before its removed logs
+			canonical, err := isCanonical(ctx, client, log)
+			if err != nil {
+				return false, fmt.Errorf("checking block of log in tx=%s: %w", log.TxHash.Hex(), err)
+			}
+			if !canonical && polling {
+				// later logs are fetched again along with the replacements
+				l.Printf("Log in tx=%s was orphaned, fetching again from block %d\n", log.TxHash.Hex(), log.BlockNumber)
+				cf.drop(log.BlockNumber)
+				block := log.BlockNumber
+				refetch = &block
+				break
+			}
+			if !canonical {
+				// held until its removal arrives or it's stale
+				cf.add(log)
+				continue
+			}
+			if !li.handleLog(ctx, log) {
+				return false, nil
+			}
+		}
+		metrics.UnconfirmedLogs.Set(float64(cf.len()))
+		return true, nil
+	}
+	// page handles fetched logs up to block end, those which are deep enough
+	// at head are confirmed already and emitted along with the cursor at end
+	page := func(head uint64) func([]types.Log, uint64) bool {
+		return func(logs []types.Log, end uint64) bool {
+			confirmed := []Event{}
+			for _, log := range logs {
+				if log.BlockNumber+depth > head+1 {
+					cf.add(log)
+				} else if e, ok := li.decode(log); ok {
+					confirmed = append(confirmed, e)
+				}
+			}
+			return li.flush(ctx, confirmed, li.cursorAt(ctx, client, end, cf))
+		}
+	}
+
+	// subscribe to new heads to release confirmed logs and move the cursor
+	var heads chan *types.Header
+	var headErrs <-chan error
+	if !polling {
+		heads = make(chan *types.Header)
+		headSub, err := client.SubscribeNewHead(ctx, heads)
+		if err != nil {
+			return err
+		}
+		defer headSub.Unsubscribe()
+		headErrs = headSub.Err()
 	}
 
-	// subscribe to new events
-	logs := make(chan types.Log)
-	sub, err := client.SubscribeFilterLogs(context.Background(), query, logs)
+	// subscribe to new events before reading history so that none is missed
+	// in between, those the history covers already are skipped
+	var logs chan types.Log
+	var logErrs <-chan error
+	if !polling {
+		logs = make(chan types.Log)
+		sub, err := client.SubscribeFilterLogs(ctx, query, logs)
+		if err != nil {
+			return err
+		}
+		defer sub.Unsubscribe()
+		logErrs = sub.Err()
+	}
+
+	// handle historic events
+	head, err := headNumber(ctx, client)
 	if err != nil {
-		l.Fatal(err)
+		return err
+	}
+	if err = pager.fetch(ctx, from.Uint64(), head, page(head)); err != nil || ctx.Err() != nil {
+		return err
+	}
+	if ok, err := release(head); !ok || !li.flush(ctx, nil, li.cursorAt(ctx, client, head, cf)) {
+		return err
+	}
+	if polling {
+		rewind := func(next uint64) uint64 {
+			if refetch != nil && *refetch < next {
+				next = *refetch
+			}
+			refetch = nil
+			return next
+		}
+		return poll(ctx, client, pager, head+1, page, release, rewind)
 	}
 
 	for {
 		select {
-		case err := <-sub.Err():
+		case <-ctx.Done():
+			l.Println("Stopped listening for contract events")
+			return nil
+		case err := <-logErrs:
 			// e.g. "i/o timeout" error which happens after 5 min idle
 			l.Println("Subscription error", err)
-			return
+			metrics.SubscriptionErrors.Inc()
+			return err
+		case err := <-headErrs:
+			l.Println("Head subscription error", err)
+			metrics.SubscriptionErrors.Inc()
+			return err
 		case log := <-logs:
-			handleLog(log)
+			if log.BlockNumber <= head && !log.Removed {
+				continue
+			}
+			if !ingest(log) {
+				return nil
+			}
+		case header := <-heads:
+			number := header.Number.Uint64()
+			if ok, err := release(number); !ok {
+				return err
+			}
+			// logs of the latest blocks may still be on their way
+			if end := number - liveCheckpointBlocks; number > liveCheckpointBlocks && end >= li.checkpointed+liveCheckpointBlocks {
+				if !li.flush(ctx, nil, li.cursorAt(ctx, client, end, cf)) {
+					return nil
+				}
+			}
 		}
 	}
 }
 
-// UpdateCurrentBlock periodically updates the passed integer to latest block
-func UpdateCurrentBlock(current *uint64) {
-	client, err := ethclient.Dial(os.Getenv("CONTRACT_L1_WSS"))
-	if err != nil {
-		l.Fatal(err)
-	}
+// poll fetches logs from next up to the latest block every PollInterval for
+// endpoints without subscriptions, since removed logs aren't seen this way a
+// CONFIRMATION_DEPTH should be set when polling. rewind moves next back to
+// where logs were found orphaned on release.
+func poll(ctx context.Context, client ChainClient, pager *logPager, next uint64, page func(uint64) func([]types.Log, uint64) bool, release func(uint64) (bool, error), rewind func(uint64) uint64) error {
+	ticker := time.NewTicker(PollInterval())
+	defer ticker.Stop()
 	for {
-		header, err := client.HeaderByNumber(context.Background(), nil)
+		select {
+		case <-ctx.Done():
+			l.Println("Stopped polling for contract events")
+			return nil
+		case <-ticker.C:
+		}
+		head, err := headNumber(ctx, client)
+		if err != nil {
+			metrics.SubscriptionErrors.Inc()
+			return err
+		}
+		next = rewind(next)
+		handle := page(head)
+		err = pager.fetch(ctx, next, head, func(logs []types.Log, end uint64) bool {
+			next = end + 1
+			return handle(logs, end)
+		})
 		if err != nil {
-			l.Fatal(err)
+			metrics.SubscriptionErrors.Inc()
+			return err
+		}
+		if ctx.Err() != nil {
+			return nil
+		}
+		if ok, err := release(head); !ok {
+			return err
 		}
+	}
+}
 
-		*current = header.Number.Uint64()
-		l.Println("Current block is at", *current)
-		time.Sleep(time.Second * 5 * 60)
+func headNumber(ctx context.Context, client ChainClient) (uint64, error) {
+	header, err := client.HeaderByNumber(ctx, nil)
+	if err != nil {
+		return 0, err
 	}
+	return header.Number.Uint64(), nil
 }
 
-func handleLog(log types.Log) {
+// isCanonical reports whether the block of log is still part of the chain
+func isCanonical(ctx context.Context, client ChainClient, log types.Log) (bool, error) {
+	header, err := client.HeaderByNumber(ctx, new(big.Int).SetUint64(log.BlockNumber))
+	if err != nil {
+		return false, err
+	}
+	return header.Hash() == log.BlockHash, nil
+}
+
+// handleLog returns false if ctx was done before the event could be emitted
+func (li *listener) handleLog(ctx context.Context, log types.Log) bool {
+	e, ok := li.decode(log)
+	if !ok {
+		return true
+	}
+	return li.emit(ctx, e)
+}
+
+// decode returns the event of log, or false if it isn't one we know of
+func (li *listener) decode(log types.Log) (Event, bool) {
 	switch log.Topics[0] {
 	case newTopUpHash:
-		handleNewTopUpLog(log)
+		return li.decodeNewTopUp(log)
 	case deploymentStoppedHash:
-		handleDeploymentStopped(log)
+		return li.decodeDeploymentStopped(log)
+	case rateChangedHash, ownerChangedHash, deploymentOwnerChangedHash:
+		return li.decodeChange(log)
+	}
+	return Event{}, false
+}
+
+func (li *listener) emit(ctx context.Context, e Event) bool {
+	e.Source = li.source.String()
+	select {
+	case <-ctx.Done():
+		return false
+	case li.c <- e:
+		return true
 	}
 }
 
-func handleNewTopUpLog(log types.Log) {
-	org := fmt.Sprintf("0x%x", log.Data[12:32])      // 0  - 32 -- last 20 bytes
-	expiry := binary.BigEndian.Uint64(log.Data[56:]) // 32 - 64 -- last  8 bytes
+func (li *listener) decodeNewTopUp(log types.Log) (Event, bool) {
+	ev, err := li.filterer.ParseNewTopUp(log)
+	if err != nil {
+		l.Printf("Failed to decode NewTopUp in tx=%s err=%v\n", log.TxHash.Hex(), err)
+		return Event{}, false
+	}
+	org := orgString(ev.Org)
+	expiry := ev.Expiry
 
 	l.Printf(
-		"NewTopUp for org=%s expiry=%d emittedAt=%d\n",
-		org, expiry, log.BlockNumber,
+		"NewTopUp for org=%s expiry=%d emittedAt=%d source=%s\n",
+		org, expiry, log.BlockNumber, li.source,
 	)
-	c <- Event{
+	countEvent(TopUpEvent, log.Removed)
+	return Event{
 		Org:         org,
 		Expiry:      expiry,
 		BlockNumber: log.BlockNumber,
 		BlockAndTx:  append(log.BlockHash[:], log.TxHash[:]...),
+		LogIndex:    log.Index,
 		Removed:     log.Removed,
 		Type:        TopUpEvent,
-	}
+	}, true
 }
 
-func handleDeploymentStopped(log types.Log) {
-	org := fmt.Sprintf("0x%x", log.Data[12:32])
-	expiry := binary.BigEndian.Uint64(log.Data[56:])
+func (li *listener) decodeDeploymentStopped(log types.Log) (Event, bool) {
+	ev, err := li.filterer.ParseDeploymentStopped(log)
+	if err != nil {
+		l.Printf("Failed to decode DeploymentStopped in tx=%s err=%v\n", log.TxHash.Hex(), err)
+		return Event{}, false
+	}
+	org := orgString(ev.Org)
+	expiry := ev.Expiry
 
 	l.Printf(
-		"DeploymentStopped for org=%s expiry=%d emittedAt=%d\n",
-		org, expiry, log.BlockNumber,
+		"DeploymentStopped for org=%s expiry=%d emittedAt=%d source=%s\n",
+		org, expiry, log.BlockNumber, li.source,
 	)
-	c <- Event{
+	countEvent(DeploymentStoppedEvent, log.Removed)
+	return Event{
 		Org:         org,
 		Expiry:      expiry,
 		BlockNumber: log.BlockNumber,
 		BlockAndTx:  append(log.BlockHash[:], log.TxHash[:]...),
+		LogIndex:    log.Index,
 		Removed:     log.Removed,
 		Type:        DeploymentStoppedEvent,
+	}, true
+}
+
+// decodeChange decodes changes of the rate, the contract owner or a
+// deployment owner
+func (li *listener) decodeChange(log types.Log) (Event, bool) {
+	e := Event{
+		BlockNumber: log.BlockNumber,
+		BlockAndTx:  append(log.BlockHash[:], log.TxHash[:]...),
+		LogIndex:    log.Index,
+		Removed:     log.Removed,
 	}
+	var err error
+	switch log.Topics[0] {
+	case rateChangedHash:
+		var ev *contract.RadicleCloudRateChanged
+		if ev, err = li.filterer.ParseRateChanged(log); err == nil {
+			e.Type, e.Rate = RateChangedEvent, ev.Rate
+		}
+	case ownerChangedHash:
+		var ev *contract.RadicleCloudOwnerChanged
+		if ev, err = li.filterer.ParseOwnerChanged(log); err == nil {
+			e.Type, e.Owner = OwnerChangedEvent, orgString(ev.Owner)
+		}
+	case deploymentOwnerChangedHash:
+		var ev *contract.RadicleCloudDeploymentOwnerChanged
+		if ev, err = li.filterer.ParseDeploymentOwnerChanged(log); err == nil {
+			e.Type, e.Org, e.Owner = DeploymentOwnerChangedEvent, orgString(ev.Org), orgString(ev.Owner)
+		}
+	}
+	if err != nil {
+		l.Printf("Failed to decode change in tx=%s err=%v\n", log.TxHash.Hex(), err)
+		return Event{}, false
+	}
+
+	l.Printf(
+		"%s org=%s owner=%s rate=%d emittedAt=%d source=%s\n",
+		e.Type.String(), e.Org, e.Owner, e.Rate, log.BlockNumber, li.source,
+	)
+	countEvent(e.Type, log.Removed)
+	return e, true
+}
+
+// orgString formats org the way it's stored in DB
+func orgString(org common.Address) string {
+	return strings.ToLower(org.Hex())
+}
+
+func countEvent(et EventType, removed bool) {
+	metrics.EventsReceived.WithLabelValues(et.String(), strconv.FormatBool(removed)).Inc()
 }
 
 func (et *EventType) String() string {
@@ -169,7 +482,31 @@ func (et *EventType) String() string {
 		return "NewTopUp"
 	case DeploymentStoppedEvent:
 		return "DeploymentStopped"
+	case RateChangedEvent:
+		return "RateChanged"
+	case OwnerChangedEvent:
+		return "OwnerChanged"
+	case DeploymentOwnerChangedEvent:
+		return "DeploymentOwnerChanged"
+	case CheckpointEvent:
+		return "Checkpoint"
 	default:
 		return ""
 	}
 }
+
+// ParseEventType returns the EventType of its String representation
+func ParseEventType(s string) (EventType, bool) {
+	for _, et := range []EventType{TopUpEvent, DeploymentStoppedEvent, RateChangedEvent, OwnerChangedEvent, DeploymentOwnerChangedEvent} {
+		if et.String() == s {
+			return et, true
+		}
+	}
+	return 0, false
+}
+
+// IsDeploymentEvent reports whether events of this type start or stop
+// deployments, the others only change settings of the contract
+func (et *EventType) IsDeploymentEvent() bool {
+	return *et == TopUpEvent || *et == DeploymentStoppedEvent
+}
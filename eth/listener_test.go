@@ -0,0 +1,81 @@
+// SPDX-License-Identifier: Apache-2.0
+
+package eth
+
+import (
+	"context"
+	"radicle-cloud/eth/contract"
+	"testing"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/core/types"
+)
+
+func TestHandleLog(t *testing.T) {
+	contractABI, err := contract.RadicleCloudMetaData.GetAbi()
+	if err != nil {
+		t.Fatal(err)
+	}
+	filterer, err := contract.NewRadicleCloudFilterer(common.Address{}, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	source := Source{Chain: "l2", Address: common.HexToAddress("0x01")}
+	li := &listener{source: source, filterer: filterer, c: make(chan Event, 1)}
+
+	org := common.HexToAddress("0xCEAA01bd5A428d2910C82BBEfE1Bc7a8cc6207D9")
+	data, err := contractABI.Events["NewTopUp"].Inputs.Pack(org, uint64(888888888))
+	if err != nil {
+		t.Fatal(err)
+	}
+	// same layout the operator used to slice by hand
+	if len(data) != 64 || common.BytesToAddress(data[12:32]) != org {
+		t.Fatalf("Unexpected NewTopUp layout %x", data)
+	}
+
+	ok := li.handleLog(context.Background(), types.Log{
+		Topics:      []common.Hash{newTopUpHash},
+		Data:        data,
+		BlockNumber: 42,
+		TxHash:      common.HexToHash("0x01"),
+		BlockHash:   common.HexToHash("0x02"),
+	})
+	if !ok {
+		t.Fatal("Expected: event to be emitted")
+	}
+	e := <-li.c
+	if e.Org != "0xceaa01bd5a428d2910c82bbefe1bc7a8cc6207d9" {
+		t.Errorf("Expected: lowercase org, Actual: %s", e.Org)
+	}
+	if e.Expiry != 888888888 || e.BlockNumber != 42 || e.Type != TopUpEvent || e.Source != source.String() {
+		t.Errorf("Unexpected event %+v", e)
+	}
+
+	data, err = contractABI.Events["DeploymentOwnerChanged"].Inputs.Pack(org, common.HexToAddress("0x03"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	ok = li.handleLog(context.Background(), types.Log{
+		Topics:  []common.Hash{deploymentOwnerChangedHash},
+		Data:    data,
+		TxHash:  common.HexToHash("0x04"),
+		Removed: true,
+	})
+	if !ok {
+		t.Fatal("Expected: change to be emitted")
+	}
+	e = <-li.c
+	if e.Type != DeploymentOwnerChangedEvent || e.Type.IsDeploymentEvent() || !e.Removed ||
+		e.Org != "0xceaa01bd5a428d2910c82bbefe1bc7a8cc6207d9" || e.Owner != "0x0000000000000000000000000000000000000003" {
+		t.Errorf("Unexpected change %+v", e)
+	}
+
+	// malformed payloads are skipped instead of misparsed
+	ok = li.handleLog(context.Background(), types.Log{
+		Topics: []common.Hash{deploymentStoppedHash},
+		Data:   data[:40],
+	})
+	if !ok || len(li.c) != 0 {
+		t.Error("Expected: malformed DeploymentStopped to be skipped")
+	}
+}
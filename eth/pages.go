@@ -0,0 +1,88 @@
+// SPDX-License-Identifier: Apache-2.0
+
+package eth
+
+import (
+	"context"
+	"math/big"
+	"os"
+	"radicle-cloud/metrics"
+	"strconv"
+
+	"github.com/ethereum/go-ethereum"
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/core/types"
+)
+
+// LogPageSize returns the most blocks fetched per eth_getLogs call, read from
+// LOG_PAGE_SIZE and defaulting to 2000
+func LogPageSize() uint64 {
+	v := os.Getenv("LOG_PAGE_SIZE")
+	if v == "" {
+		return 2000
+	}
+	size, err := strconv.ParseUint(v, 10, 64)
+	if err != nil || size == 0 {
+		l.Fatal("Invalid LOG_PAGE_SIZE ", v)
+	}
+	return size
+}
+
+// logPager fetches the logs of a block range in pages. Providers cap the
+// range or the number of results of eth_getLogs, so a page which fails is
+// halved and retried and the size grows back while pages succeed.
+type logPager struct {
+	source Source
+	client ChainClient
+	query  ethereum.FilterQuery
+	size   uint64
+	max    uint64
+}
+
+func newLogPager(source Source, client ChainClient, max uint64) *logPager {
+	if max == 0 {
+		max = 1
+	}
+	query := ethereum.FilterQuery{Addresses: []common.Address{source.Address}}
+	return &logPager{source: source, client: client, query: query, size: max, max: max}
+}
+
+// fetch calls page with the logs of every page from block from to block to,
+// both inclusive, in order. It stops early if page returns false and fails
+// once a single block can't be fetched.
+func (p *logPager) fetch(ctx context.Context, from uint64, to uint64, page func(logs []types.Log, end uint64) bool) error {
+	for from <= to {
+		end := from + p.size - 1
+		if end > to || end < from {
+			end = to
+		}
+		query := p.query
+		query.FromBlock = new(big.Int).SetUint64(from)
+		query.ToBlock = new(big.Int).SetUint64(end)
+		logs, err := p.client.FilterLogs(ctx, query)
+		if err != nil {
+			if ctx.Err() != nil || p.size == 1 {
+				return err
+			}
+			p.size /= 2
+			l.Printf("Failed to fetch logs of blocks %d-%d, retrying %d blocks at a time: %v\n", from, end, p.size, err)
+			metrics.LogPageSize.WithLabelValues(p.source.String()).Set(float64(p.size))
+			continue
+		}
+		if !page(logs, end) {
+			return nil
+		}
+		if end == to {
+			break
+		}
+		from = end + 1
+		if p.size < p.max {
+			p.size *= 2
+			if p.size > p.max {
+				p.size = p.max
+			}
+			metrics.LogPageSize.WithLabelValues(p.source.String()).Set(float64(p.size))
+		}
+	}
+	return nil
+}
@@ -0,0 +1,57 @@
+// SPDX-License-Identifier: Apache-2.0
+
+package eth
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	"github.com/ethereum/go-ethereum"
+	"github.com/ethereum/go-ethereum/core/types"
+)
+
+// rangeLimitedClient rejects eth_getLogs over more than limit blocks
+type rangeLimitedClient struct {
+	ChainClient
+	limit  uint64
+	ranges [][2]uint64
+}
+
+func (c *rangeLimitedClient) FilterLogs(ctx context.Context, q ethereum.FilterQuery) ([]types.Log, error) {
+	from, to := q.FromBlock.Uint64(), q.ToBlock.Uint64()
+	c.ranges = append(c.ranges, [2]uint64{from, to})
+	if to-from+1 > c.limit {
+		return nil, errors.New("block range too large")
+	}
+	return []types.Log{{BlockNumber: from}}, nil
+}
+
+func TestLogPager(t *testing.T) {
+	client := &rangeLimitedClient{limit: 3}
+	p := newLogPager(Source{Chain: "test"}, client, 8)
+	ends := []uint64{}
+	err := p.fetch(context.Background(), 10, 29, func(logs []types.Log, end uint64) bool {
+		ends = append(ends, end)
+		return true
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	// 8 and 4 are rejected, 2 succeeds and grows to 4 which is rejected again
+	expected := []uint64{11, 13, 15, 17, 19, 21, 23, 25, 27, 29}
+	if len(ends) != len(expected) {
+		t.Fatalf("Expected: %v, Actual: %v", expected, ends)
+	}
+	for i := range expected {
+		if ends[i] != expected[i] {
+			t.Fatalf("Expected: %v, Actual: %v", expected, ends)
+		}
+	}
+
+	// a single block which can't be fetched fails
+	client.limit = 0
+	if err = p.fetch(context.Background(), 30, 40, func([]types.Log, uint64) bool { return true }); err == nil {
+		t.Fatal("Expected: an error")
+	}
+}
@@ -0,0 +1,36 @@
+// SPDX-License-Identifier: Apache-2.0
+
+package eth
+
+import (
+	"context"
+	"radicle-cloud/eth/contract"
+
+	"github.com/ethereum/go-ethereum/core/types"
+)
+
+// Replay emits the events of source's contract from block from to block to,
+// both inclusive, to ec. Logs are fetched at most pageSize blocks at a time
+// to stay within the range limits of providers.
+func Replay(ctx context.Context, client ChainClient, source Source, from uint64, to uint64, pageSize uint64, ec chan Event) error {
+	filterer, err := contract.NewRadicleCloudFilterer(source.Address, client)
+	if err != nil {
+		return err
+	}
+	li := &listener{source: source, filterer: filterer, c: ec}
+	stopped := false
+	err = newLogPager(source, client, pageSize).fetch(ctx, from, to, func(logs []types.Log, end uint64) bool {
+		for _, log := range logs {
+			if !li.handleLog(ctx, log) {
+				stopped = true
+				return false
+			}
+		}
+		l.Printf("Replayed blocks up to %d of %s\n", end, source)
+		return true
+	})
+	if err == nil && stopped {
+		return ctx.Err()
+	}
+	return err
+}
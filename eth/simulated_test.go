@@ -0,0 +1,343 @@
+// SPDX-License-Identifier: Apache-2.0
+
+package eth
+
+import (
+	"context"
+	"math/big"
+	"os"
+	"radicle-cloud/eth/contract"
+	"testing"
+	"time"
+
+	"github.com/ethereum/go-ethereum/accounts/abi"
+	"github.com/ethereum/go-ethereum/accounts/abi/bind"
+	"github.com/ethereum/go-ethereum/accounts/abi/bind/backends"
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/core"
+	"github.com/ethereum/go-ethereum/crypto"
+)
+
+// emitterCode deploys a contract which logs whatever it's called with, the
+// first 32 bytes of calldata are the topic and the rest is the data. It lets
+// us emit the events of RadicleCloudBase on a simulated chain without solc.
+//
+//	init:    PUSH1 0x12 DUP1 PUSH1 0x0b PUSH1 0 CODECOPY PUSH1 0 RETURN
+//	runtime: PUSH1 0x20 CALLDATASIZE SUB DUP1 PUSH1 0x20 PUSH1 0 CALLDATACOPY
+//	         PUSH1 0 CALLDATALOAD SWAP1 PUSH1 0 LOG1 STOP
+var emitterCode = common.FromHex("0x601280600b6000396000f3" + "60203603806020600037600035906000a100")
+
+// simChain is a simulated chain with an emitter contract deployed
+type simChain struct {
+	t       *testing.T
+	backend *backends.SimulatedBackend
+	auth    *bind.TransactOpts
+	emitter *bind.BoundContract
+	source  Source
+}
+
+func newSimChain(t *testing.T) *simChain {
+	key, err := crypto.GenerateKey()
+	if err != nil {
+		t.Fatal(err)
+	}
+	auth, err := bind.NewKeyedTransactorWithChainID(key, big.NewInt(1337))
+	if err != nil {
+		t.Fatal(err)
+	}
+	backend := backends.NewSimulatedBackend(core.GenesisAlloc{
+		auth.From: {Balance: new(big.Int).Lsh(big.NewInt(1), 100)},
+	}, 8000000)
+	t.Cleanup(func() { backend.Close() })
+
+	address, _, emitter, err := bind.DeployContract(auth, abi.ABI{}, emitterCode, backend)
+	if err != nil {
+		t.Fatal(err)
+	}
+	backend.Commit()
+	return &simChain{
+		t:       t,
+		backend: backend,
+		auth:    auth,
+		emitter: emitter,
+		source:  Source{Chain: "sim", Address: address},
+	}
+}
+
+// emit logs event name of RadicleCloudBase with args in the pending block
+func (s *simChain) emit(name string, args ...interface{}) {
+	contractABI, err := contract.RadicleCloudMetaData.GetAbi()
+	if err != nil {
+		s.t.Fatal(err)
+	}
+	ev := contractABI.Events[name]
+	data, err := ev.Inputs.Pack(args...)
+	if err != nil {
+		s.t.Fatal(err)
+	}
+	if _, err = s.emitter.RawTransact(s.auth, append(ev.ID.Bytes(), data...)); err != nil {
+		s.t.Fatal(err)
+	}
+}
+
+// commit mines the pending block and gives the filter system time to deliver
+// its logs, otherwise they may reach a subscription made right after
+func (s *simChain) commit() {
+	s.backend.Commit()
+	time.Sleep(50 * time.Millisecond)
+}
+
+// reorg replaces the last depth blocks by a longer chain of empty blocks
+func (s *simChain) reorg(depth uint64) {
+	head, err := s.backend.HeaderByNumber(context.Background(), nil)
+	if err != nil {
+		s.t.Fatal(err)
+	}
+	parent, err := s.backend.HeaderByNumber(context.Background(), new(big.Int).SetUint64(head.Number.Uint64()-depth))
+	if err != nil {
+		s.t.Fatal(err)
+	}
+	if err = s.backend.Fork(context.Background(), parent.Hash()); err != nil {
+		s.t.Fatal(err)
+	}
+	for i := uint64(0); i <= depth; i++ {
+		s.commit()
+	}
+}
+
+// listen runs Listen from genesis until the test ends
+func (s *simChain) listen(polling bool) chan Event {
+	ctx, cancel := context.WithCancel(context.Background())
+	ec := make(chan Event, 10)
+	done := make(chan struct{})
+	go func() {
+		defer close(done)
+		if err := Listen(ctx, s.backend, polling, s.source, ec, big.NewInt(0)); err != nil {
+			s.t.Error(err)
+		}
+	}()
+	s.t.Cleanup(func() {
+		cancel()
+		<-done
+	})
+	// give the subscriptions time to be set up
+	time.Sleep(100 * time.Millisecond)
+	return ec
+}
+
+// next returns the next event which isn't a checkpoint
+func next(t *testing.T, ec chan Event) Event {
+	for {
+		select {
+		case e := <-ec:
+			if e.Type != CheckpointEvent {
+				return e
+			}
+		case <-time.After(5 * time.Second):
+			t.Fatal("Expected: an event")
+		}
+	}
+}
+
+// none checks that no event but checkpoints arrive
+func none(t *testing.T, ec chan Event) {
+	timeout := time.After(200 * time.Millisecond)
+	for {
+		select {
+		case e := <-ec:
+			if e.Type != CheckpointEvent {
+				t.Fatalf("Expected: no event, Actual: %+v", e)
+			}
+		case <-timeout:
+			return
+		}
+	}
+}
+
+func TestSimulatedReorg(t *testing.T) {
+	s := newSimChain(t)
+	org := common.HexToAddress("0x02")
+
+	// historic events are read before subscribing
+	s.emit("NewTopUp", org, uint64(300))
+	s.commit()
+	ec := s.listen(false)
+	e := next(t, ec)
+	if e.Type != TopUpEvent || e.Expiry != 300 || e.Removed || e.Source != s.source.String() {
+		t.Fatalf("Unexpected historic event %+v", e)
+	}
+
+	s.emit("DeploymentStopped", org, uint64(3))
+	s.commit()
+	stopped := next(t, ec)
+	if stopped.Type != DeploymentStoppedEvent || stopped.Removed {
+		t.Fatalf("Unexpected event %+v", stopped)
+	}
+
+	// the block with the stop is orphaned
+	s.reorg(1)
+	e = next(t, ec)
+	if e.Type != DeploymentStoppedEvent || !e.Removed || string(e.BlockAndTx) != string(stopped.BlockAndTx) {
+		t.Fatalf("Expected: stop to be removed, Actual: %+v", e)
+	}
+	none(t, ec)
+}
+
+func TestSimulatedConfirmations(t *testing.T) {
+	os.Setenv("CONFIRMATION_DEPTH", "2")
+	defer os.Unsetenv("CONFIRMATION_DEPTH")
+	s := newSimChain(t)
+	ec := s.listen(false)
+	org := common.HexToAddress("0x02")
+
+	// an orphaned top-up never reaches the operator
+	s.emit("NewTopUp", org, uint64(300))
+	s.commit()
+	none(t, ec)
+	s.reorg(1)
+	none(t, ec)
+
+	// a top-up is released once it's two blocks deep
+	s.emit("NewTopUp", org, uint64(400))
+	s.commit()
+	none(t, ec)
+	s.commit()
+	e := next(t, ec)
+	if e.Type != TopUpEvent || e.Expiry != 400 || e.Removed {
+		t.Fatalf("Unexpected confirmed event %+v", e)
+	}
+	none(t, ec)
+}
+
+func TestSimulatedPollingReorg(t *testing.T) {
+	os.Setenv("CONFIRMATION_DEPTH", "3")
+	defer os.Unsetenv("CONFIRMATION_DEPTH")
+	os.Setenv("CHAIN_POLL_INTERVAL", "10ms")
+	defer os.Unsetenv("CHAIN_POLL_INTERVAL")
+	s := newSimChain(t)
+	ec := s.listen(true)
+	org := common.HexToAddress("0x02")
+
+	// a held top-up is polled and its block orphaned
+	s.emit("NewTopUp", org, uint64(300))
+	s.commit()
+	orphaned, err := s.backend.HeaderByNumber(context.Background(), nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	number := orphaned.Number.Uint64()
+	parent, err := s.backend.HeaderByNumber(context.Background(), new(big.Int).SetUint64(number-1))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err = s.backend.Fork(context.Background(), parent.Hash()); err != nil {
+		t.Fatal(err)
+	}
+
+	// it's mined again in another block at the same height, which was polled
+	// already
+	s.emit("NewTopUp", org, uint64(300))
+	s.commit()
+	s.commit()
+	none(t, ec)
+	s.commit()
+	e := next(t, ec)
+	if e.Type != TopUpEvent || e.Expiry != 300 || e.Removed || e.BlockNumber != number {
+		t.Fatalf("Unexpected refetched event %+v", e)
+	}
+	header, err := s.backend.HeaderByNumber(context.Background(), new(big.Int).SetUint64(number))
+	if err != nil || header.Hash() == orphaned.Hash() {
+		t.Fatalf("Expected: block %d to be replaced, Actual: %v", number, err)
+	}
+	none(t, ec)
+}
+
+func TestSimulatedReplay(t *testing.T) {
+	s := newSimChain(t)
+	org := common.HexToAddress("0x02")
+	s.emit("NewTopUp", org, uint64(300))
+	s.commit()
+	s.commit()
+	s.emit("RateChanged", uint64(7))
+	s.emit("DeploymentStopped", org, uint64(4))
+	s.commit()
+
+	// pages of two blocks split the range 1-4 in the middle
+	ec := make(chan Event, 10)
+	if err := Replay(context.Background(), s.backend, s.source, 1, 4, 2, ec); err != nil {
+		t.Fatal(err)
+	}
+	close(ec)
+	types := []EventType{}
+	for e := range ec {
+		types = append(types, e.Type)
+	}
+	if len(types) != 3 || types[0] != TopUpEvent || types[1] != RateChangedEvent || types[2] != DeploymentStoppedEvent {
+		t.Fatalf("Unexpected replayed events %v", types)
+	}
+}
+
+func TestSimulatedCursors(t *testing.T) {
+	os.Setenv("LOG_PAGE_SIZE", "2")
+	defer os.Unsetenv("LOG_PAGE_SIZE")
+	s := newSimChain(t)
+	org := common.HexToAddress("0x02")
+	s.emit("NewTopUp", org, uint64(300))
+	s.commit()
+	for i := 0; i < 3; i++ {
+		s.commit()
+	}
+
+	// history of blocks 0-5 is read in pages, the cursor at the end of a page
+	// comes with its last event or on its own
+	ec := s.listen(false)
+	expected := []Event{
+		{Type: CheckpointEvent, BlockNumber: 1, Cursor: &Cursor{Block: 1}},
+		{Type: TopUpEvent, BlockNumber: 2, Cursor: &Cursor{Block: 3}},
+		{Type: CheckpointEvent, BlockNumber: 5, Cursor: &Cursor{Block: 5}},
+	}
+	for _, want := range expected {
+		e := <-ec
+		if e.Type != want.Type || e.BlockNumber != want.BlockNumber || e.Cursor == nil || e.Cursor.Block != want.Cursor.Block {
+			t.Fatalf("Expected: %s at %d, Actual: %+v", want.Type.String(), want.BlockNumber, e)
+		}
+		header, err := s.backend.HeaderByNumber(context.Background(), new(big.Int).SetUint64(e.Cursor.Block))
+		if err != nil || header.Hash() != e.Cursor.Hash {
+			t.Fatalf("Expected: cursor hash %s, Actual: %s", header.Hash().Hex(), e.Cursor.Hash.Hex())
+		}
+	}
+
+	// new events follow the history exactly once
+	s.emit("NewTopUp", org, uint64(400))
+	s.commit()
+	if e := <-ec; e.Type != TopUpEvent || e.Expiry != 400 || e.Cursor != nil {
+		t.Fatalf("Unexpected live event %+v", e)
+	}
+	select {
+	case e := <-ec:
+		t.Fatalf("Expected: no event, Actual: %+v", e)
+	case <-time.After(200 * time.Millisecond):
+	}
+}
+
+func TestSimulatedResume(t *testing.T) {
+	s := newSimChain(t)
+	for i := 0; i < 3; i++ {
+		s.commit()
+	}
+	header, err := s.backend.HeaderByNumber(context.Background(), big.NewInt(3))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// listening resumes after the cursor
+	from, err := Cursor{Block: 3, Hash: header.Hash()}.resume(context.Background(), s.backend, s.source)
+	if err != nil || from != 4 {
+		t.Fatalf("Expected: 4, Actual: %d %v", from, err)
+	}
+	// unless the cursor's block was orphaned
+	from, err = Cursor{Block: 3, Hash: common.HexToHash("0x01")}.resume(context.Background(), s.backend, s.source)
+	if err != nil || from != 0 {
+		t.Fatalf("Expected: 0, Actual: %d %v", from, err)
+	}
+}
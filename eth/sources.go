@@ -0,0 +1,101 @@
+// SPDX-License-Identifier: Apache-2.0
+
+package eth
+
+import (
+	"fmt"
+	"os"
+	"strconv"
+	"strings"
+
+	"github.com/ethereum/go-ethereum/common"
+)
+
+// Source is a contract on a chain whose events are followed
+type Source struct {
+	Chain      string
+	Address    common.Address
+	StartBlock uint64
+}
+
+// String identifies the source in DB e.g. l2:0xabc...
+func (s Source) String() string {
+	return s.Chain + ":" + orgString(s.Address)
+}
+
+// Sources returns the contracts listed in CONTRACT_SOURCES as comma separated
+// chain:address[@startBlock], defaulting to CONTRACT_ADDRESS on chain l2
+func Sources() ([]Source, error) {
+	v := os.Getenv("CONTRACT_SOURCES")
+	if strings.TrimSpace(v) == "" {
+		v = "l2:" + os.Getenv("CONTRACT_ADDRESS")
+	}
+	return ParseSources(v)
+}
+
+// ParseSources parses a comma separated list of chain:address[@startBlock]
+// and rejects sources which don't count expiries on the L1 clock
+func ParseSources(v string) ([]Source, error) {
+	sources := []Source{}
+	seen := map[string]bool{}
+	for _, entry := range strings.Split(v, ",") {
+		entry = strings.TrimSpace(entry)
+		if entry == "" {
+			continue
+		}
+		parts := strings.SplitN(entry, ":", 2)
+		if len(parts) != 2 || parts[0] == "" {
+			return nil, fmt.Errorf("source %q is not chain:address", entry)
+		}
+		s := Source{Chain: strings.ToLower(parts[0])}
+		address := parts[1]
+		if i := strings.Index(address, "@"); i >= 0 {
+			start, err := strconv.ParseUint(address[i+1:], 10, 64)
+			if err != nil {
+				return nil, fmt.Errorf("source %q has an invalid start block", entry)
+			}
+			s.StartBlock = start
+			address = address[:i]
+		}
+		if !common.IsHexAddress(address) {
+			return nil, fmt.Errorf("source %q has an invalid address", entry)
+		}
+		s.Address = common.HexToAddress(address)
+		// expiries of all sources are merged and compared with the L1 block
+		if !OnL1Clock(s.Chain) {
+			return nil, fmt.Errorf("source %s doesn't count expiries in L1 blocks, set CHAIN_%s_CLOCK=l1 if it does", s, strings.ToUpper(s.Chain))
+		}
+		if seen[s.String()] {
+			return nil, fmt.Errorf("source %s is listed twice", s)
+		}
+		seen[s.String()] = true
+		sources = append(sources, s)
+	}
+	if len(sources) == 0 {
+		return nil, fmt.Errorf("no contract sources configured")
+	}
+	return sources, nil
+}
+
+// OnL1Clock reports whether contracts on chain count expiries in L1 block
+// numbers. That's block.number on l1 and on Arbitrum as l2, any other chain
+// has to declare it with CHAIN_<NAME>_CLOCK=l1.
+func OnL1Clock(chain string) bool {
+	switch chain {
+	case "l1", "l2":
+		return true
+	}
+	return strings.ToLower(os.Getenv("CHAIN_"+strings.ToUpper(chain)+"_CLOCK")) == "l1"
+}
+
+// ChainEndpoints returns the RPC endpoints of chain, l2 and l1 are read from
+// CONTRACT_L2_WSS and CONTRACT_L1_WSS, any other chain from CHAIN_<NAME>_WSS
+func ChainEndpoints(chain string) *Endpoints {
+	switch chain {
+	case "l2":
+		return NewEndpoints(os.Getenv("CONTRACT_L2_WSS"))
+	case "l1":
+		return NewEndpoints(os.Getenv("CONTRACT_L1_WSS"))
+	}
+	return NewEndpoints(os.Getenv("CHAIN_" + strings.ToUpper(chain) + "_WSS"))
+}
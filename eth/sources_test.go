@@ -0,0 +1,32 @@
+// SPDX-License-Identifier: Apache-2.0
+
+package eth
+
+import (
+	"os"
+	"testing"
+)
+
+func TestParseSources(t *testing.T) {
+	os.Setenv("CHAIN_MAINNET_CLOCK", "l1")
+	defer os.Unsetenv("CHAIN_MAINNET_CLOCK")
+	sources, err := ParseSources("l2:0xCEAA01bd5A428d2910C82BBEfE1Bc7a8cc6207D9@1200, Mainnet:0x00000000000000000000000000000000000000aa")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(sources) != 2 {
+		t.Fatalf("expected 2 sources, got %v", sources)
+	}
+	if s := sources[0].String(); s != "l2:0xceaa01bd5a428d2910c82bbefe1bc7a8cc6207d9" || sources[0].StartBlock != 1200 {
+		t.Errorf("unexpected first source %s from %d", s, sources[0].StartBlock)
+	}
+	if sources[1].Chain != "mainnet" || sources[1].StartBlock != 0 {
+		t.Errorf("unexpected second source %+v", sources[1])
+	}
+
+	for _, v := range []string{"", "0xceaa01bd5a428d2910c82bbefe1bc7a8cc6207d9", "l2:0x01@x", "l2:nope", "l2:0x00000000000000000000000000000000000000aa,L2:0x00000000000000000000000000000000000000AA", "optimism:0x00000000000000000000000000000000000000aa"} {
+		if _, err := ParseSources(v); err == nil {
+			t.Errorf("expected %q to be rejected", v)
+		}
+	}
+}
@@ -10,5 +10,7 @@ require (
 	github.com/hetznercloud/hcloud-go v1.33.1
 	github.com/joho/godotenv v1.4.0
 	github.com/lib/pq v1.0.0
+	github.com/miekg/dns v1.1.43
+	github.com/prometheus/client_golang v1.11.0
 	golang.org/x/sys v0.0.0-20210817190340-bfb29a6856f2 // indirect
 )
@@ -3,13 +3,24 @@
 package main
 
 import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
 	"log"
-	"math/big"
 	"os"
+	"os/signal"
+	"radicle-cloud/api"
 	"radicle-cloud/cloud"
 	"radicle-cloud/db"
 	"radicle-cloud/eth"
+	"radicle-cloud/metrics"
+	"radicle-cloud/reconcile"
 	"radicle-cloud/utils"
+	"strconv"
+	"sync"
+	"sync/atomic"
+	"syscall"
 	"time"
 
 	"github.com/joho/godotenv"
@@ -17,43 +28,273 @@ import (
 
 var l *log.Logger
 
+// retryBackoff spaces out retries of failed events
+var retryBackoff = utils.Backoff{Base: 30 * time.Second, Max: time.Hour}
+
+// retryMaxAttempts is how often an event is tried before it's dead
+var retryMaxAttempts = 8
+
+// retryInterval is how often due retries are looked up
+var retryInterval = 15 * time.Second
+
+// storeBackoff spaces out attempts to store an event while DB fails, the
+// event can't be recorded as failed before it's stored
+var storeBackoff = utils.Backoff{Base: time.Second, Max: time.Minute}
+
+// leaderPollInterval is how often standbys try to become leader
+var leaderPollInterval = 2 * time.Second
+
+// terminationBackoff spaces out attempts to terminate an expired deployment
+var terminationBackoff = utils.Backoff{Base: 30 * time.Second, Max: 30 * time.Minute}
+
+// chainBackoff spaces out reconnects to the RPC endpoints
+var chainBackoff = utils.Backoff{Base: time.Second, Max: time.Minute}
+
+// sources are the contracts whose events are followed
+var sources []eth.Source
+
+// runAnsible configures a reserved server, it's replaced in tests
+var runAnsible = cloud.RunAnsible
+
 func init() {
 	l = log.New(os.Stderr, "[MAIN]	", log.Ldate|log.Ltime|log.Lshortfile)
-	setup()
 }
 
 func main() {
+	if len(os.Args) > 1 {
+		runCommand(os.Args[1:])
+		return
+	}
+	setup()
+
+	// ctx is cancelled on SIGINT/SIGTERM and stops taking on new work
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt, syscall.SIGTERM)
+	defer stop()
+	// workCtx outlives ctx so in-flight work can finish within the timeout
+	workCtx, cancelWork := context.WithCancel(context.Background())
+	defer cancelWork()
+
 	var currentBlock uint64 = 0
-	go eth.UpdateCurrentBlock(&currentBlock)
-	for currentBlock == 0 {
+	blockTimer := eth.NewBlockTimer(eth.DefaultBlockTime())
+	// holds the latest head only, it's read by the expiry scheduler
+	heads := make(chan uint64, 1)
+	reorgs := make(chan eth.Reorg, 1)
+	l1 := l1Endpoints()
+	go reconnect(ctx, "L1 client", func() error {
+		return eth.UpdateCurrentBlock(ctx, l1, &currentBlock, blockTimer, heads, reorgs)
+	})
+	go runAdminAPI(ctx, &currentBlock)
+
+	// only the leader listens to the contract and provisions, standbys wait
+	l.Println("Waiting for leadership")
+	lead, err := db.Campaign(ctx, leaderPollInterval)
+	if err != nil {
+		l.Println("Stopped waiting for leadership", err)
+		return
+	}
+	l.Println("Acquired leadership")
+	metrics.IsLeader.Set(1)
+	defer lead.Resign()
+	// closed when leadership was lost while leading, not after stopping
+	lostLeadership := make(chan struct{})
+	ctx, stopLeading := context.WithCancel(ctx)
+	defer stopLeading()
+	go func() {
+		select {
+		case <-ctx.Done():
+		case <-lead.Lost():
+			// another replica may take over any moment so stop right away
+			l.Println("Lost leadership, stopping")
+			close(lostLeadership)
+			metrics.IsLeader.Set(0)
+			stopLeading()
+			cancelWork()
+		}
+	}()
+
+	for atomic.LoadUint64(&currentBlock) == 0 {
 		l.Println("Initializing current block")
-		time.Sleep(time.Second)
+		select {
+		case <-ctx.Done():
+			return
+		case <-time.After(time.Second):
+		}
 	}
 
+	var orgsWG, expiryWG sync.WaitGroup
 	channels := map[string]chan eth.Event{}
 	ethEvents := make(chan eth.Event)
+	retryEvents := make(chan eth.Event)
 	stateEvents := make(chan db.Dep)
-	go runEthListener(ethEvents, &currentBlock)
-	go terminateExpiringOrgs(stateEvents, &currentBlock)
-
+	// events stored before sources were tracked came from the first one
+	if n, err := db.AdoptEvents(workCtx, sources[0].String()); err != nil {
+		l.Fatal("Failed to adopt events for ", sources[0], err)
+	} else if n > 0 {
+		l.Println("Adopted", n, "events for", sources[0])
+	}
+	for _, source := range sources {
+		// listening resumes after events which may not have been processed
+		if n, err := db.RequeueInterrupted(workCtx, source.String()); err != nil {
+			l.Fatal("Failed to requeue interrupted events of ", source, err)
+		} else if n > 0 {
+			l.Println("Requeued", n, "interrupted events of", source)
+		}
+		go runEthListener(ctx, workCtx, source, ethEvents)
+	}
+	go retryFailedEvents(ctx, retryEvents)
+	expiryWG.Add(1)
+	go func() {
+		defer expiryWG.Done()
+		terminateExpiringOrgs(ctx, workCtx, stateEvents, heads, blockTimer, &currentBlock)
+	}()
+	go runReconciler(ctx)
+
+LOOP:
 	for {
 		// stream events from contract
-		e := <-ethEvents
+		var e eth.Event
+		select {
+		case <-ctx.Done():
+			break LOOP
+		case e = <-ethEvents:
+			if !storeEventWithRetry(ctx, workCtx, &e, &currentBlock) {
+				continue LOOP
+			}
+		// failed events are already stored
+		case e = <-retryEvents:
+			if e.Removed {
+				if err := stateAfterRemoval(workCtx, &e, &currentBlock); err != nil {
+					recordFailure(workCtx, e, err)
+					continue LOOP
+				}
+			}
+		case r := <-reorgs:
+			handleReorg(r, heads)
+			continue LOOP
+		}
+
+		ch, chCreated := getOrCreateOrgChannel(e.Org, &channels)
+		if chCreated {
+			orgsWG.Add(1)
+			go func(org string) {
+				defer orgsWG.Done()
+				processEventsForOrg(ctx, workCtx, org, ch, stateEvents)
+			}(e.Org)
+		}
+		select {
+		case <-ctx.Done():
+			break LOOP
+		case ch <- e:
+		}
+	}
+
+	shutdown(channels, &orgsWG, &expiryWG, stateEvents, cancelWork)
+	select {
+	case <-lostLeadership:
+		// exit non-zero so that the container is restarted as a standby
+		lead.Resign()
+		os.Exit(1)
+	default:
+	}
+}
 
-		if err := db.UpsertEvent(e); err != nil {
-			l.Fatal("Failed to upsert event", e, err)
+// storeEvent stores e coming from chain and reports whether it must be
+// processed for its org. A removed event whose state can't be restored is
+// stored and recorded as failed.
+func storeEvent(ctx context.Context, e *eth.Event, currentBlock *uint64) (bool, error) {
+	// all events up to a checkpoint are stored by now
+	if e.Type == eth.CheckpointEvent {
+		if err := db.SaveCursor(ctx, e.Source, *e.Cursor); err != nil {
+			l.Println("Failed to save cursor of", e.Source, "at", e.BlockNumber, err)
+		}
+		return false, nil
+	}
+	// changes of the contract only need to be stored
+	if !e.Type.IsDeploymentEvent() {
+		if err := db.RecordChange(ctx, *e); err != nil {
+			return false, fmt.Errorf("recording change: %w", err)
 		}
+		return false, nil
+	}
+	if err := db.UpsertEvent(ctx, *e); err != nil {
+		return false, fmt.Errorf("upserting event: %w", err)
+	}
 
-		// if event has been removed, a reorg has happened
-		if e.Removed {
-			stateAfterRemoval(&e, &currentBlock)
+	// if event has been removed, a reorg has happened
+	if e.Removed {
+		if err := stateAfterRemoval(ctx, e, currentBlock); err != nil {
+			l.Printf("Failed to restore state after removal for org=%s err=%v\n", e.Org, err)
+			recordFailure(ctx, *e, err)
+			return false, nil
 		}
+	}
+	return true, nil
+}
 
-		ch, chCreated := getOrCreateOrgChannel(e.Org, &channels)
-		if chCreated {
-			go processEventsForOrg(e.Org, ch, stateEvents)
+// storeEventWithRetry stores e with workCtx and backs off while that fails
+// until ctx is done, listening doesn't move past an event which isn't stored
+func storeEventWithRetry(ctx context.Context, workCtx context.Context, e *eth.Event, currentBlock *uint64) bool {
+	for attempt := 1; ; attempt++ {
+		ok, err := storeEvent(workCtx, e, currentBlock)
+		if err == nil {
+			return ok
 		}
-		ch <- e
+		delay := storeBackoff.Delay(attempt)
+		l.Printf("Failed to store event for org=%s, retrying in %v err=%v\n", e.Org, delay, err)
+		select {
+		case <-ctx.Done():
+			return false
+		case <-time.After(delay):
+		}
+	}
+}
+
+// shutdown waits for in-flight org work and terminations to finish. Events
+// which were not processed yet are left unprocessed in DB and picked up from
+// chain again on restart.
+func shutdown(channels map[string]chan eth.Event, orgsWG *sync.WaitGroup, expiryWG *sync.WaitGroup, stateEvents chan db.Dep, cancelWork context.CancelFunc) {
+	timeout := 90 * time.Second
+	if v := os.Getenv("SHUTDOWN_TIMEOUT"); v != "" {
+		var err error
+		if timeout, err = time.ParseDuration(v); err != nil {
+			l.Println("Invalid SHUTDOWN_TIMEOUT, using", timeout, err)
+		}
+	}
+	l.Println("Shutting down, waiting up to", timeout, "for in-flight work")
+	deadline := time.Now().Add(timeout)
+
+	for _, ch := range channels {
+		close(ch)
+	}
+	if !waitUntil(orgsWG, deadline) {
+		l.Println("Org work did not finish in time, cancelling it")
+		cancelWork()
+		if !waitUntil(orgsWG, time.Now().Add(5*time.Second)) {
+			l.Println("Org work did not stop after cancellation")
+			return
+		}
+	}
+	// no org worker can notify the expiry loop anymore
+	close(stateEvents)
+	if !waitUntil(expiryWG, deadline) {
+		l.Println("Terminations did not finish in time, cancelling them")
+		cancelWork()
+		waitUntil(expiryWG, time.Now().Add(5*time.Second))
+	}
+	l.Println("Shutdown complete")
+}
+
+func waitUntil(wg *sync.WaitGroup, deadline time.Time) bool {
+	done := make(chan struct{})
+	go func() {
+		wg.Wait()
+		close(done)
+	}()
+	select {
+	case <-done:
+		return true
+	case <-time.After(time.Until(deadline)):
+		return false
 	}
 }
 
@@ -67,211 +308,493 @@ func getOrCreateOrgChannel(org string, channels *map[string]chan eth.Event) (cha
 	return chs[org], true
 }
 
-func processEventsForOrg(org string, ch chan eth.Event, stateEvents chan db.Dep) {
+// processEventsForOrg processes events of org one by one with workCtx and
+// stops picking up new ones once ctx is done. Failed events are recorded and
+// retried later by retryFailedEvents so that org keeps flowing.
+func processEventsForOrg(ctx context.Context, workCtx context.Context, org string, ch chan eth.Event, stateEvents chan db.Dep) {
 	for e := range ch {
-		tries := 3
-		for {
-			tries--
-			if ok := processEvent(e, stateEvents); ok {
-				break
-			}
+		if ctx.Err() != nil {
+			return
+		}
+		if err := processEvent(workCtx, e, stateEvents); err != nil {
+			l.Printf("Failed to process event for org=%s err=%v\n", org, err)
+			recordFailure(workCtx, e, err)
+			continue
+		}
+		if err := db.ResolveFailure(workCtx, e); err != nil {
+			l.Println("Failed to resolve failure of event for org", org, err)
+		}
+	}
+}
+
+// recordFailure schedules a retry of e with exponential backoff, or marks it
+// dead once it ran out of attempts
+func recordFailure(ctx context.Context, e eth.Event, reason error) {
+	attempts, err := db.RecordFailure(ctx, e, reason.Error())
+	if err != nil {
+		l.Println("Failed to record failure of event for org", e.Org, err)
+		return
+	}
+	if attempts >= retryMaxAttempts {
+		l.Printf("Event for org=%s failed %d times, moving it to dead letters\n", e.Org, attempts)
+		metrics.EventsDeadLettered.Inc()
+		if err = db.MarkDead(ctx, e); err != nil {
+			l.Println("Failed to mark event dead for org", e.Org, err)
+		}
+		return
+	}
+	delay := retryBackoff.Delay(attempts)
+	l.Printf("Retrying event for org=%s in %v (attempt %d/%d)\n", e.Org, delay, attempts, retryMaxAttempts)
+	if err = db.ScheduleRetry(ctx, e, time.Now().Add(delay)); err != nil {
+		l.Println("Failed to schedule retry of event for org", e.Org, err)
+	}
+}
+
+// retryFailedEvents hands out failed events whose retry is due to rc
+func retryFailedEvents(ctx context.Context, rc chan eth.Event) {
+	if err := db.UnclaimFailures(ctx); err != nil {
+		l.Println("Failed to reschedule claimed failures", err)
+	}
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-time.After(retryInterval):
+		}
 
-			if tries == 0 {
-				l.Fatal("Failed to process event for org", org, e)
+		if n, err := db.ResolveSupersededFailures(ctx); err != nil {
+			l.Println("Failed to resolve superseded failures", err)
+		} else if n > 0 {
+			l.Println("Resolved", n, "failures superseded by later events")
+		}
+		events, err := db.ClaimDueFailures(ctx)
+		if err != nil {
+			l.Println("Failed to claim due failures", err)
+			continue
+		}
+		for _, e := range events {
+			select {
+			case <-ctx.Done():
+				return
+			case rc <- e:
 			}
-			time.Sleep(time.Second * 10)
 		}
 	}
 }
 
-func processEvent(e eth.Event, stateEvents chan db.Dep) bool {
+// processEvent moves the deployment of e's org through its states until it's
+// running, every step is persisted first so that it resumes after a crash
+func processEvent(ctx context.Context, e eth.Event, stateEvents chan db.Dep) error {
 	l.Printf("Processing: %+v\n", e)
 
 	if e.Type == eth.DeploymentStoppedEvent {
-		provider, err := db.GetProvider(e.Org)
-		if err != nil {
-			l.Fatal("Failed to get provider for org", e.Org, err)
-		}
-		stateEvents <- db.Dep{Org: e.Org, Expiry: e.Expiry, Provider: provider}
-		return true
+		return stopDeployment(ctx, e, stateEvents)
 	}
 
 	// upsert deployment, update expiry if already exists
-	provider, ip, status, err := db.UpsertDep(e)
+	dep, err := db.UpsertDep(ctx, e)
 	if err != nil {
-		l.Fatal("Error upserting org", e.Org, err)
-	}
-	if status == db.RunningStatus && provider != "" {
-		// org existed, updated expiry, and we can exit
-		stateEvents <- db.Dep{Org: e.Org, Expiry: e.Expiry, Provider: provider}
-		return true
+		return fmt.Errorf("upserting deployment: %w", err)
 	}
+	provider, ip, status, expiry := dep.Provider, dep.IP, dep.Status, dep.Expiry
+	for {
+		switch status {
+		case db.InitialStatus:
+			// reserve a server
+			provider, ip, err = cloud.ReserveServer(ctx, e.Org)
+			if err != nil {
+				metrics.EventsProcessed.WithLabelValues("reserve-failed").Inc()
+				return fmt.Errorf("reserving server: %w", err)
+			}
+			// the id is only kept for history so a failed lookup isn't fatal
+			serverID, err := cloud.ServerID(ctx, e.Org, provider)
+			if err != nil {
+				l.Println("Failed to look up server id of", e.Org, "at", provider, err)
+			}
+			if err = db.UpdateOrgServer(ctx, e.Org, ip, provider, serverID); err != nil {
+				metrics.EventsProcessed.WithLabelValues("reserve-failed").Inc()
+				return fmt.Errorf("updating ip: %w", err)
+			}
+			status = db.AllocatedStatus
 
-	switch status {
-	// case db.InitialStatus:
-	// straight ahead
-	case db.AllocatedStatus:
-		goto ALLOCATED
-	case db.SetupFailedStatus:
-		goto SETUP
-	case db.RunningStatus:
-		goto RUNNING
-	}
+		case db.AllocatedStatus:
+			// create dns record for org subdomain
+			if err = cloud.CreateDNS(ctx, e.Org, ip); err != nil {
+				metrics.EventsProcessed.WithLabelValues("dns-failed").Inc()
+				return fmt.Errorf("creating dns record: %w", err)
+			}
+			if err = db.Transition(ctx, e.Org, status, db.DNSCreatedStatus, "created dns record"); err != nil {
+				return fmt.Errorf("setting status to '%s': %w", db.DNSCreatedStatus, err)
+			}
+			status = db.DNSCreatedStatus
+
+		case db.DNSCreatedStatus, db.SetupFailedStatus:
+			// run ansible for initial setup
+			if err = runAnsible(ctx, e.Org, ip, 10); err != nil {
+				// failed after 10 retries
+				l.Println("Failed to complete configuration after 10 tries for", e.Org, ip, err)
+				metrics.EventsProcessed.WithLabelValues(db.SetupFailedStatus).Inc()
+				if serr := db.Transition(ctx, e.Org, status, db.SetupFailedStatus, err.Error()); serr != nil {
+					l.Println("Failed to set status to 'setup-failed' for", e.Org, ip, serr)
+				}
+				return fmt.Errorf("running ansible: %w", err)
+			}
+			l.Println("Configured org", e.Org, "with ip", ip)
+			if err = db.Transition(ctx, e.Org, status, db.RunningStatus, "configured server"); err != nil {
+				return fmt.Errorf("setting status to 'running': %w", err)
+			}
+			l.Printf("Org %s status set to 'running' in DB", e.Org)
+			status = db.RunningStatus
 
-	// reserve a server
-	provider, ip, err = cloud.ReserveServer(e.Org)
-	if err != nil {
-		l.Println("Couldn't reserve server", err)
-		return false
-	}
+		case db.SuspendedStatus:
+			// the server was kept until expiry so it only needs to be paid again
+			if err = db.Transition(ctx, e.Org, status, db.RunningStatus, "paid again"); err != nil {
+				return fmt.Errorf("setting status to 'running': %w", err)
+			}
+			status = db.RunningStatus
 
-	// update ip and provider for deployment and set status to allocated
-	if err = db.UpdateOrgServer(e.Org, ip, provider); err != nil {
-		l.Println("Failed updating ip for org", e.Org, err)
-		return false
-	}
+		case db.RunningStatus:
+			metrics.EventsProcessed.WithLabelValues(db.RunningStatus).Inc()
+			stateEvents <- db.Dep{Org: e.Org, Expiry: expiry, Provider: provider}
+			return markProcessed(ctx, e)
 
-ALLOCATED:
-	// create dns record for org subdomain
-	if err = cloud.CreateDNS(e.Org, ip); err != nil {
-		if err.Error() != "HTTP status 400: Record already exists. (81057)" {
-			l.Println("Failed to create dns record for org", e.Org, err)
-			return false
+		case db.TerminatingStatus:
+			// it's retried and starts over once the termination finished
+			return fmt.Errorf("deployment of %s is still terminating", e.Org)
+
+		default:
+			return fmt.Errorf("deployment of %s has unknown status '%s'", e.Org, status)
 		}
 	}
+}
 
-SETUP:
-RUNNING:
-	if status != db.RunningStatus {
-		// run ansible for initial setup
-		err = cloud.RunAnsible(e.Org, ip, 10)
-	} else {
-		// ansible already configured this
-		err = nil
+// stopDeployment hands the merged expiry of a stopped deployment to the expiry
+// scheduler, a running one which isn't paid through another source anymore is
+// suspended until then
+func stopDeployment(ctx context.Context, e eth.Event, stateEvents chan db.Dep) error {
+	dep, err := db.GetDeployment(ctx, e.Org)
+	if err != nil {
+		return fmt.Errorf("getting deployment: %w", err)
 	}
+	// a deployment still paid for through another source keeps running
+	expiry, err := db.MergedExpiry(ctx, e.Org)
 	if err != nil {
-		// failed after 10 retries
-		l.Println("Failed to complete configuration after 10 tries for", e.Org, ip, err)
-		if err = db.SetStatus(e.Org, "setup-failed"); err != nil {
-			l.Println("Failed to set status to 'setup-failed' for", e.Org, ip, err)
-		}
-	} else {
-		l.Println("Configured org", e.Org, "with ip", ip)
-		// flip status to runnning
-		if err = db.SetStatus(e.Org, "running"); err != nil {
-			l.Println("Failed to set status to 'running' for", e.Org, ip, err)
-		} else {
-			l.Printf("Org %s status set to 'running' in DB", e.Org)
-			stateEvents <- db.Dep{Org: e.Org, Expiry: e.Expiry, Provider: provider}
-			if err = db.MarkEventProcessed(e.BlockAndTx); err != nil {
-				return true
-			}
-			return false
+		return fmt.Errorf("merging expiry: %w", err)
+	}
+	if dep.Status == db.RunningStatus && expiry <= e.BlockNumber {
+		reason := fmt.Sprintf("stopped through %s at block %d", e.Source, e.BlockNumber)
+		if err = db.Transition(ctx, e.Org, dep.Status, db.SuspendedStatus, reason); err != nil {
+			return fmt.Errorf("setting status to '%s': %w", db.SuspendedStatus, err)
 		}
 	}
-	return false
+	stateEvents <- db.Dep{Org: e.Org, Expiry: expiry, Provider: dep.Provider}
+	metrics.EventsProcessed.WithLabelValues("stopped").Inc()
+	return markProcessed(ctx, e)
+}
+
+// markProcessed marks e processed so that listening doesn't resume before it
+func markProcessed(ctx context.Context, e eth.Event) error {
+	if err := db.MarkEventProcessed(ctx, e.BlockAndTx, e.LogIndex); err != nil {
+		return fmt.Errorf("marking event processed: %w", err)
+	}
+	return nil
 }
 
 func setup() {
+	loadEnv()
+	db.Setup()
+	cloud.Setup()
+
+	if v := os.Getenv("EVENT_RETRY_MAX_ATTEMPTS"); v != "" {
+		attempts, err := strconv.Atoi(v)
+		if err != nil || attempts < 1 {
+			l.Fatal("Invalid EVENT_RETRY_MAX_ATTEMPTS ", v)
+		}
+		retryMaxAttempts = attempts
+	}
+	if v := os.Getenv("EVENT_RETRY_BACKOFF"); v != "" {
+		base, err := time.ParseDuration(v)
+		if err != nil || base <= 0 {
+			l.Fatal("Invalid EVENT_RETRY_BACKOFF ", v)
+		}
+		retryBackoff.Base = base
+	}
+
+	var err error
+	if sources, err = eth.Sources(); err != nil {
+		l.Fatal("Invalid CONTRACT_SOURCES ", err)
+	}
+}
+
+func loadEnv() {
 	err := godotenv.Load()
 	if err != nil {
 		log.Fatal("Error loading .env file", err)
 	}
+}
 
-	db.Setup()
-	cloud.Setup()
+// getCursor returns the cursor to resume listening to source after, sources
+// stored before cursors existed resume from their events
+func getCursor(ctx context.Context, source eth.Source) (eth.Cursor, error) {
+	cursor, err := db.GetCursor(ctx, source.String())
+	if err != sql.ErrNoRows {
+		return cursor, err
+	}
+	lastProcessed, err := db.GetLastProcessedBlock(ctx, source.String())
+	if err == sql.ErrNoRows {
+		l.Println("No events stored for", source, "listening from block", source.StartBlock)
+		lastProcessed = 0
+	} else if err != nil {
+		return cursor, fmt.Errorf("getting last processed block of %s: %w", source, err)
+	}
+	// nothing of interest happened before the contract was deployed
+	if lastProcessed < source.StartBlock {
+		lastProcessed = source.StartBlock
+	}
+	if lastProcessed > 0 {
+		cursor.Block = lastProcessed - 1
+	}
+	return cursor, nil
 }
 
-func getLastProcessedBlock(current *uint64) *big.Int {
-	var last big.Int
-	lastProcessed, err := db.GetLastProcessedBlock()
-	if err != nil {
-		l.Println("Error getting last processed block", err)
-		last.SetUint64(*current)
+// l1Endpoints returns the endpoints the current block is read from, on
+// rollups the L1 block number can be read from the L2 node instead
+func l1Endpoints() *eth.Endpoints {
+	if eth.L1BlockFromL2() {
+		return eth.NewEndpoints(os.Getenv("CONTRACT_L2_WSS"))
 	}
-	last.SetUint64(lastProcessed)
-	return &last
+	return eth.NewEndpoints(os.Getenv("CONTRACT_L1_WSS"))
 }
 
-func runEthListener(ec chan eth.Event, currentBlock *uint64) {
-	for {
-		eth.StartListening(ec, getLastProcessedBlock(currentBlock))
-		time.Sleep(time.Second * 5)
+func runEthListener(ctx context.Context, workCtx context.Context, source eth.Source, ec chan eth.Event) {
+	endpoints := eth.ChainEndpoints(source.Chain)
+	reconnect(ctx, "Listener of "+source.String(), func() error {
+		cursor, err := getCursor(workCtx, source)
+		if err != nil {
+			return err
+		}
+		return eth.StartListening(ctx, endpoints, source, ec, cursor)
+	})
+}
+
+// reconnect calls run until ctx is done, backing off while it keeps failing
+func reconnect(ctx context.Context, name string, run func() error) {
+	attempt := 0
+	for ctx.Err() == nil {
+		started := time.Now()
+		err := run()
+		if ctx.Err() != nil {
+			return
+		}
+		// a connection which held up for a while starts over with short delays
+		if time.Since(started) > chainBackoff.Max {
+			attempt = 0
+		}
+		attempt++
+		delay := chainBackoff.Delay(attempt)
+		l.Printf("%s disconnected, reconnecting in %s: %v\n", name, delay, err)
+		select {
+		case <-ctx.Done():
+		case <-time.After(delay):
+		}
 	}
 }
 
-func terminateExpiringOrgs(c chan db.Dep, currentBlock *uint64) {
+func runAdminAPI(ctx context.Context, currentBlock *uint64) {
+	addr := os.Getenv("ADMIN_ADDR")
+	if addr == "" {
+		addr = "127.0.0.1:8080"
+	}
+	if err := api.Serve(ctx, addr, os.Getenv("ADMIN_TOKEN"), currentBlock); err != nil {
+		l.Fatal("Admin API stopped", err)
+	}
+}
+
+func runReconciler(ctx context.Context) {
+	interval := time.Hour
+	if v := os.Getenv("RECONCILE_INTERVAL"); v != "" {
+		var err error
+		if interval, err = time.ParseDuration(v); err != nil {
+			l.Fatal("Invalid RECONCILE_INTERVAL", err)
+		}
+	}
+	if interval == 0 {
+		l.Println("Reconciler is disabled")
+		return
+	}
+	reconcile.Run(ctx, interval, os.Getenv("RECONCILE_REPAIR") == "true")
+}
+
+// terminateExpiringOrgs terminates orgs which expired EXPIRY_FINALITY_BLOCKS
+// ago with workCtx until ctx is done and keeps consuming c until it's closed.
+// Orgs renewed in DB before c caught up are scheduled again and failed
+// terminations are retried with backoff.
+func terminateExpiringOrgs(ctx context.Context, workCtx context.Context, c chan db.Dep, heads chan uint64, bt *eth.BlockTimer, currentBlock *uint64) {
+	// expiries are only acted on once a reorg can't take them back
+	finality := eth.ExpiryFinality()
 	// list all deployments with ascending expiring date
-	deps, err := db.ListDeployments()
+	deps, err := db.ListDeployments(workCtx)
 	if err != nil {
 		l.Fatal("Can't list Deployments", err)
 	}
-	s := new(utils.ExpiryState).Init().AddDeps(deps)
+	// terminating deployments were interrupted and have expired already
+	active := []db.Dep{}
+	for _, dep := range deps {
+		if dep.Status != db.TerminatedStatus {
+			active = append(active, dep)
+		}
+	}
+	s := new(utils.ExpiryState).Init().AddDeps(active)
+	// failed terminations by org, they're retried with backoff
+	retries := map[string]*pendingTermination{}
+	terminate := func(dep db.Dep, expiry uint64) {
+		renewed, err := terminateDeployment(workCtx, dep.Org, expiry)
+		if err == nil {
+			delete(retries, dep.Org)
+			if renewed != nil {
+				s.AddOrUpdateDep(*renewed)
+			}
+			return
+		}
+		r, ok := retries[dep.Org]
+		if !ok {
+			r = &pendingTermination{dep: dep, expiry: expiry}
+			retries[dep.Org] = r
+		}
+		r.attempts++
+		r.at = time.Now().Add(terminationBackoff.Delay(r.attempts))
+		l.Printf("Failed to terminate org=%s, retrying in %v (attempt %d) err=%v\n",
+			dep.Org, time.Until(r.at).Round(time.Second), r.attempts, err)
+	}
 
 	for {
+		for _, r := range retries {
+			if ctx.Err() == nil && !time.Now().Before(r.at) {
+				terminate(r.dep, r.expiry)
+			}
+		}
 		// more than an event can be in a block so loop through all of them
-		for {
+		for ctx.Err() == nil {
 			// take a peek to check if smallest block in heap has expired
 			block, ok := s.Peek()
 			if !ok {
 				// break out of loop if there's nothing
 				break
 			}
-			if block <= *currentBlock {
-				for _, dep := range s.GetDeps(block) {
+			if block+finality <= atomic.LoadUint64(currentBlock) {
+				due := s.GetDeps(block)
+				s.Next() // clean up
+				for _, dep := range due {
 					l.Printf("Deployment for org=%s has expired\n", dep.Org)
-					if cloud.TerminateOrg(dep.Org, dep.Provider) {
-						l.Println("Cloud resource was terminated for", dep.Org, "in", dep.Provider)
-					}
-					if err := db.DeleteOrg(dep.Org); err != nil {
-						time.Sleep(5 * time.Second)
-						l.Fatalf("Failed to delete org=%s provider=%s err=%v\n", dep.Org, dep.Provider, err)
-					}
+					terminate(dep, block)
 				}
-				s.Next() // clean up
 			} else {
 				// min of heap is still higher than current block
 				break
 			}
 		}
 
+		metrics.ExpiryStateSize.Set(float64(s.Len()))
+		// new heads wake us up when the block arrives, the estimate only
+		// matters while no heads come in
 		nextAwake := 3600 * time.Second
-		if block, ok := s.Peek(); ok {
-			nextAwake = time.Duration((block-*currentBlock)*14) * time.Second
+		if block, ok := s.Peek(); ok && block+finality > atomic.LoadUint64(currentBlock) {
+			nextAwake = bt.Until(block+finality, atomic.LoadUint64(currentBlock))
+		}
+		for _, r := range retries {
+			if until := time.Until(r.at); until < nextAwake {
+				nextAwake = until
+			}
 		}
 
 		select {
 		// sleep until next org expires
 		case <-time.After(nextAwake):
+		// or a new block is mined
+		case <-heads:
 		// or a new event happens
-		case e := <-c:
+		case e, ok := <-c:
+			if !ok {
+				return
+			}
 			s.AddOrUpdateDep(e)
 		}
 	}
 }
 
-func stateAfterRemoval(e *eth.Event, currentBlock *uint64) {
-	events, err := db.ListOrgEvents(e.Org)
+// pendingTermination is a failed termination waiting to be tried again at
+type pendingTermination struct {
+	dep      db.Dep
+	expiry   uint64
+	attempts int
+	at       time.Time
+}
+
+// terminateDeployment persists that the deployment of org is terminating
+// before tearing down its server and record. It returns the deployment if it
+// was renewed past expiry instead, and an error if the termination has to be
+// tried again.
+func terminateDeployment(ctx context.Context, org string, expiry uint64) (*db.Dep, error) {
+	dep, ok, err := db.BeginTermination(ctx, org, expiry)
+	if errors.Is(err, sql.ErrNoRows) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("setting status to '%s': %w", db.TerminatingStatus, err)
+	}
+	if !ok {
+		if dep.Status == db.TerminatedStatus {
+			return nil, nil
+		}
+		l.Printf("Deployment for org=%s was renewed until block %d\n", org, dep.Expiry)
+		return &dep, nil
+	}
+	// a deployment which never got a server has nothing to tear down
+	if dep.Provider != "" {
+		if !cloud.TerminateOrg(ctx, org, dep.Provider) {
+			return nil, fmt.Errorf("terminating cloud resources at %s", dep.Provider)
+		}
+		l.Println("Cloud resource was terminated for", org, "in", dep.Provider)
+	}
+	if err = db.MarkTerminated(ctx, org, "terminated server and dns record"); err != nil {
+		return nil, fmt.Errorf("setting status to '%s': %w", db.TerminatedStatus, err)
+	}
+	return nil, nil
+}
+
+// handleReorg warns about reorgs deeper than the expiry finality and has the
+// expiry scheduler look at the new current block
+func handleReorg(r eth.Reorg, heads chan uint64) {
+	if r.Depth > eth.ExpiryFinality() {
+		l.Printf("Reorg of %d L1 blocks is deeper than EXPIRY_FINALITY_BLOCKS, deployments may have been terminated early\n", r.Depth)
+	}
+	select {
+	case heads <- r.Number:
+	default:
+	}
+}
+
+// stateAfterRemoval turns removed e into the latest state of its org's
+// deployment at e's source
+func stateAfterRemoval(ctx context.Context, e *eth.Event, currentBlock *uint64) error {
+	events, err := db.ListOrgEvents(ctx, e.Org, e.Source)
 	if err != nil {
-		l.Fatal("Error processing removal for org", e.Org, err)
+		return fmt.Errorf("listing events: %w", err)
 	}
 
+	current := atomic.LoadUint64(currentBlock)
 	if len(events) > 0 {
 		event := events[0]
 		// if expiry is still valid, this is the latest state
-		if event.Expiry > *currentBlock {
-			// swap expiry with last valid state
+		if event.Expiry > current {
+			// swap expiry with last valid state, earlier events are kept for
+			// the history of the deployment
 			e.Expiry = event.Expiry
-			// if this event is confirmed, remove other events before it
-			if event.BlockNumber+9 <= *currentBlock {
-				if err := db.DeleteOrgEventsBefore(e.Org, event.BlockNumber); err != nil {
-					l.Println("Failed to delete events before", event.BlockNumber, "for", e.Org)
-				}
-			}
-			return
+			return nil
 		}
 	}
-	// no events means deployment should be removed
+	// no events of this source means it doesn't pay for the deployment anymore
 	e.Type = eth.DeploymentStoppedEvent
-	e.Expiry = *currentBlock
+	e.Expiry = current
+	return nil
 }
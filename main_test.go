@@ -0,0 +1,425 @@
+// SPDX-License-Identifier: Apache-2.0
+
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"math/big"
+	"os"
+	"radicle-cloud/cloud"
+	"radicle-cloud/db"
+	"radicle-cloud/eth"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/ethereum/go-ethereum/accounts/abi"
+	"github.com/ethereum/go-ethereum/accounts/abi/bind"
+	"github.com/ethereum/go-ethereum/accounts/abi/bind/backends"
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/core"
+	"github.com/ethereum/go-ethereum/crypto"
+)
+
+// fakeProvider hands out addresses without reserving anything
+type fakeProvider struct {
+	mu      sync.Mutex
+	servers map[string]string
+}
+
+func (p *fakeProvider) Name() string { return "fake" }
+func (p *fakeProvider) Setup() error {
+	p.servers = map[string]string{}
+	return nil
+}
+func (p *fakeProvider) Create(ctx context.Context, org string) (string, error) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	p.servers[org] = fmt.Sprintf("10.0.0.%d", len(p.servers)+1)
+	return p.servers[org], nil
+}
+func (p *fakeProvider) Get(ctx context.Context, org string) (*cloud.Server, error) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	if ip, ok := p.servers[org]; ok {
+		return &cloud.Server{ID: org, Org: org, IP: ip}, nil
+	}
+	return nil, nil
+}
+func (p *fakeProvider) Delete(ctx context.Context, org string) error {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	delete(p.servers, org)
+	return nil
+}
+func (p *fakeProvider) List(ctx context.Context) ([]cloud.Server, error) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	servers := []cloud.Server{}
+	for org, ip := range p.servers {
+		servers = append(servers, cloud.Server{ID: org, Org: org, IP: ip})
+	}
+	return servers, nil
+}
+
+// fakeDNS accepts every record
+type fakeDNS struct{}
+
+func (fakeDNS) Name() string                                             { return "fake" }
+func (fakeDNS) Setup(domain string) error                                { return nil }
+func (fakeDNS) Create(ctx context.Context, fqdn string, ip string) error { return nil }
+func (fakeDNS) Delete(ctx context.Context, fqdn string) error            { return nil }
+func (fakeDNS) List(ctx context.Context) ([]cloud.Record, error)         { return nil, nil }
+
+// loadContract reads abi and bytecode of name from the output of `dapp build`
+func loadContract(t *testing.T, file string, name string) (abi.ABI, []byte) {
+	raw, err := ioutil.ReadFile("contract/out/dapp.sol.json")
+	if err != nil {
+		t.Skip("Run `dapp build` in contract/ to test against the contract: ", err)
+	}
+	var out struct {
+		Contracts map[string]map[string]struct {
+			ABI json.RawMessage `json:"abi"`
+			EVM struct {
+				Bytecode struct {
+					Object string `json:"object"`
+				} `json:"bytecode"`
+			} `json:"evm"`
+		} `json:"contracts"`
+	}
+	if err = json.Unmarshal(raw, &out); err != nil {
+		t.Fatal(err)
+	}
+	c, ok := out.Contracts[file][name]
+	if !ok {
+		t.Fatalf("No %s in %s", name, file)
+	}
+	parsed, err := abi.JSON(strings.NewReader(string(c.ABI)))
+	if err != nil {
+		t.Fatal(err)
+	}
+	return parsed, common.FromHex(c.EVM.Bytecode.Object)
+}
+
+// operator drives the event pipeline of main by hand
+type operator struct {
+	t       *testing.T
+	ctx     context.Context
+	backend *backends.SimulatedBackend
+	auth    *bind.TransactOpts
+	rc      *bind.BoundContract
+	source  eth.Source
+	events  chan eth.Event
+	states  chan db.Dep
+	current uint64
+}
+
+// pump stores and processes the next n events like main does, checkpoints
+// are stored but not counted
+func (o *operator) pump(n int) []eth.Event {
+	events := []eth.Event{}
+	for len(events) < n {
+		var e eth.Event
+		select {
+		case e = <-o.events:
+		case <-time.After(5 * time.Second):
+			o.t.Fatalf("Expected: %d events, Actual: %v", n, events)
+		}
+		head, err := o.backend.HeaderByNumber(o.ctx, nil)
+		if err != nil {
+			o.t.Fatal(err)
+		}
+		o.current = head.Number.Uint64()
+		stored, err := storeEvent(o.ctx, &e, &o.current)
+		if err != nil {
+			o.t.Fatal(err)
+		}
+		if stored {
+			if err := processEvent(o.ctx, e, o.states); err != nil {
+				o.t.Fatal(err)
+			}
+		}
+		if e.Type != eth.CheckpointEvent {
+			events = append(events, e)
+		}
+	}
+	timeout := time.After(200 * time.Millisecond)
+	for {
+		select {
+		case e := <-o.events:
+			if e.Type != eth.CheckpointEvent {
+				o.t.Fatalf("Expected: no more events, Actual: %+v", e)
+			}
+			if _, err := storeEvent(o.ctx, &e, &o.current); err != nil {
+				o.t.Fatal(err)
+			}
+		case <-timeout:
+			return events
+		}
+	}
+}
+
+// state returns the last deployment handed to the expiry scheduler
+func (o *operator) state() db.Dep {
+	select {
+	case dep := <-o.states:
+		return dep
+	default:
+		o.t.Fatal("Expected: a deployment for the expiry scheduler")
+	}
+	return db.Dep{}
+}
+
+func (o *operator) commit() {
+	o.backend.Commit()
+	// the filter system delivers logs asynchronously
+	time.Sleep(50 * time.Millisecond)
+}
+
+// newOperator deploys EthRadicleCloud on a fresh simulated chain, recreates
+// the schema and listens until the test ends. The events of the constructor
+// are pumped already.
+func newOperator(t *testing.T, contractABI abi.ABI, code []byte) *operator {
+	if _, err := db.Rollback(1 << 16); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := db.Migrate(); err != nil {
+		t.Fatal(err)
+	}
+
+	key, err := crypto.GenerateKey()
+	if err != nil {
+		t.Fatal(err)
+	}
+	auth, err := bind.NewKeyedTransactorWithChainID(key, big.NewInt(1337))
+	if err != nil {
+		t.Fatal(err)
+	}
+	backend := backends.NewSimulatedBackend(core.GenesisAlloc{
+		auth.From: {Balance: new(big.Int).Lsh(big.NewInt(1), 100)},
+	}, 8000000)
+	t.Cleanup(func() { backend.Close() })
+	// 1 wei per block for 200 blocks
+	address, _, rc, err := bind.DeployContract(auth, contractABI, code, backend, uint64(1), uint32(200), auth.From)
+	if err != nil {
+		t.Fatal(err)
+	}
+	backend.Commit()
+
+	ctx, cancel := context.WithCancel(context.Background())
+	o := &operator{
+		t:       t,
+		ctx:     ctx,
+		backend: backend,
+		auth:    auth,
+		rc:      rc,
+		source:  eth.Source{Chain: "sim", Address: address},
+		events:  make(chan eth.Event, 10),
+		states:  make(chan db.Dep, 10),
+	}
+	done := make(chan struct{})
+	go func() {
+		defer close(done)
+		if err := eth.Listen(ctx, backend, false, o.source, o.events, big.NewInt(0)); err != nil {
+			t.Error(err)
+		}
+	}()
+	t.Cleanup(func() {
+		cancel()
+		<-done
+	})
+	o.pump(2)
+	return o
+}
+
+// transact calls method of the contract paying value and mines it
+func (o *operator) transact(value int64, method string, args ...interface{}) {
+	opts := *o.auth
+	opts.Value = big.NewInt(value)
+	if _, err := o.rc.Transact(&opts, method, args...); err != nil {
+		o.t.Fatal(method, err)
+	}
+	o.commit()
+}
+
+// buy purchases a deployment of org and returns its events
+func (o *operator) buy(org common.Address) []eth.Event {
+	o.transact(200, "buyOrRenew", org, o.auth.From)
+	events := o.pump(2)
+	o.state()
+	return events
+}
+
+// TestSimulatedOperator runs EthRadicleCloud on a simulated chain through the
+// listener into Postgres. TEST_POSTGRES must point to a throwaway database,
+// its schema is dropped and recreated for every scenario.
+func TestSimulatedOperator(t *testing.T) {
+	pg := os.Getenv("TEST_POSTGRES")
+	if pg == "" {
+		t.Skip("Set TEST_POSTGRES to a throwaway database to run against Postgres")
+	}
+	contractABI, code := loadContract(t, "src/EthRadicleCloud.sol", "EthRadicleCloud")
+	t.Log("Dropping the schema of the TEST_POSTGRES database")
+
+	os.Setenv("POSTGRES", pg)
+	defer os.Unsetenv("POSTGRES")
+	os.Setenv("CLOUD_PROVIDERS", "fake")
+	defer os.Unsetenv("CLOUD_PROVIDERS")
+	os.Setenv("DNS_PROVIDER", "fake")
+	defer os.Unsetenv("DNS_PROVIDER")
+	os.Setenv("DNS_DOMAIN", "radicle.test")
+	defer os.Unsetenv("DNS_DOMAIN")
+	// the simulated chain counts its own blocks like an L1
+	os.Setenv("CHAIN_SIM_CLOCK", "l1")
+	defer os.Unsetenv("CHAIN_SIM_CLOCK")
+	cloud.Register(&fakeProvider{})
+	cloud.RegisterDNS(fakeDNS{})
+	db.Connect()
+	cloud.Setup()
+	runAnsible = func(ctx context.Context, org string, ip string, retries int) error { return nil }
+	defer func() { runAnsible = cloud.RunAnsible }()
+
+	t.Run("buy and renew", func(t *testing.T) {
+		o := newOperator(t, contractABI, code)
+		owner := strings.ToLower(o.auth.From.Hex())
+
+		// the constructor announces owner and rate
+		contracts, err := db.ListContracts(o.ctx)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if len(contracts) != 1 || contracts[0].Source != o.source.String() || contracts[0].Rate != 1 || contracts[0].Owner != owner {
+			t.Fatalf("Unexpected contracts %+v", contracts)
+		}
+
+		// a purchase is provisioned until its expiry
+		o.transact(200, "buyOrRenew", common.HexToAddress("0x0a"), o.auth.From)
+		events := o.pump(2)
+		if events[0].Type != eth.DeploymentOwnerChangedEvent || events[1].Type != eth.TopUpEvent {
+			t.Fatalf("Unexpected events %+v", events)
+		}
+		dep, err := db.GetDeployment(o.ctx, events[1].Org)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if dep.Status != db.RunningStatus || dep.Provider != "fake" || dep.Expiry != events[1].BlockNumber+200 {
+			t.Fatalf("Unexpected deployment %+v", dep)
+		}
+		if scheduled := o.state(); scheduled.Expiry != dep.Expiry {
+			t.Fatalf("Expected: expiry %d scheduled, Actual: %+v", dep.Expiry, scheduled)
+		}
+		owners, err := db.DeploymentOwners(o.ctx, dep.Org)
+		if err != nil || owners[o.source.String()] != owner {
+			t.Fatalf("Unexpected owners %v %v", owners, err)
+		}
+
+		// a renewal extends it
+		o.transact(200, "buyOrRenew", common.HexToAddress("0x0a"), o.auth.From)
+		o.pump(1)
+		if scheduled := o.state(); scheduled.Expiry != dep.Expiry+200 {
+			t.Fatalf("Expected: renewal to extend expiry, Actual: %+v", scheduled)
+		}
+		// the old expiry firing before the scheduler heard of it doesn't terminate
+		if renewed, err := terminateDeployment(o.ctx, dep.Org, dep.Expiry); err != nil || renewed == nil || renewed.Expiry != dep.Expiry+200 {
+			t.Fatalf("Expected: renewed deployment to be scheduled again, Actual: %+v %v", renewed, err)
+		}
+	})
+
+	t.Run("cancel", func(t *testing.T) {
+		o := newOperator(t, contractABI, code)
+		bought := o.buy(common.HexToAddress("0x0a"))
+		dep, err := db.GetDeployment(o.ctx, bought[1].Org)
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		// cancelling expires it right away
+		for i := 0; i < 6; i++ {
+			o.commit()
+		}
+		o.transact(0, "cancelDeployment", common.HexToAddress("0x0a"))
+		events := o.pump(1)
+		if events[0].Type != eth.DeploymentStoppedEvent {
+			t.Fatalf("Unexpected events %+v", events)
+		}
+		if scheduled := o.state(); scheduled.Expiry != events[0].BlockNumber {
+			t.Fatalf("Expected: cancelled deployment to expire at %d, Actual: %+v", events[0].BlockNumber, scheduled)
+		}
+		if d, err := db.GetDeployment(o.ctx, dep.Org); err != nil || d.Status != db.SuspendedStatus {
+			t.Fatalf("Expected: cancelled deployment to be suspended, Actual: %+v %v", d, err)
+		}
+
+		// expiring terminates it but keeps its transitions
+		if renewed, err := terminateDeployment(o.ctx, dep.Org, events[0].BlockNumber); err != nil || renewed != nil {
+			t.Fatalf("Expected: termination, Actual: %+v %v", renewed, err)
+		}
+		if d, err := db.GetDeployment(o.ctx, dep.Org); err != nil || d.Status != db.TerminatedStatus {
+			t.Fatalf("Expected: expired deployment to be terminated, Actual: %+v %v", d, err)
+		}
+		transitions, err := db.ListTransitions(o.ctx, dep.Org)
+		if err != nil {
+			t.Fatal(err)
+		}
+		statuses := []string{}
+		for _, tr := range transitions {
+			statuses = append(statuses, tr.To)
+		}
+		expected := []string{db.InitialStatus, db.AllocatedStatus, db.DNSCreatedStatus, db.RunningStatus, db.SuspendedStatus, db.TerminatingStatus, db.TerminatedStatus}
+		if strings.Join(statuses, ",") != strings.Join(expected, ",") {
+			t.Fatalf("Expected: transitions %v, Actual: %v", expected, statuses)
+		}
+		history, err := db.ListHistory(o.ctx, dep.Org)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if len(history) != 1 || history[0].IP != dep.IP || history[0].ServerID != dep.Org ||
+			history[0].StartBlock != dep.StartBlock || history[0].EndBlock != events[0].BlockNumber {
+			t.Fatalf("Expected: lifetime of %+v archived, Actual: %+v", dep, history)
+		}
+		if kept, err := db.ListEvents(o.ctx, dep.Org); err != nil || len(kept) != 2 {
+			t.Fatalf("Expected: events to be kept, Actual: %+v %v", kept, err)
+		}
+	})
+
+	t.Run("suspend", func(t *testing.T) {
+		o := newOperator(t, contractABI, code)
+		o.buy(common.HexToAddress("0x0b"))
+		o.transact(0, "suspendDeployment", common.HexToAddress("0x0b"))
+		events := o.pump(1)
+		if scheduled := o.state(); events[0].Type != eth.DeploymentStoppedEvent || scheduled.Expiry != events[0].BlockNumber {
+			t.Fatalf("Expected: suspended deployment to expire, Actual: %+v", scheduled)
+		}
+	})
+
+	t.Run("reorg", func(t *testing.T) {
+		o := newOperator(t, contractABI, code)
+
+		// a purchase which is reorged away stops the deployment again
+		bought := o.buy(common.HexToAddress("0x0c"))
+		head, err := o.backend.HeaderByNumber(o.ctx, nil)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if err = o.backend.Fork(o.ctx, head.ParentHash); err != nil {
+			t.Fatal(err)
+		}
+		o.commit()
+		o.commit()
+		events := o.pump(2)
+		for i, e := range events {
+			if !e.Removed || string(e.BlockAndTx) != string(bought[i].BlockAndTx) {
+				t.Fatalf("Expected: purchase to be removed, Actual: %+v", e)
+			}
+		}
+		if scheduled := o.state(); scheduled.Org != bought[1].Org || scheduled.Expiry != 0 {
+			t.Fatalf("Expected: reorged deployment to expire, Actual: %+v", scheduled)
+		}
+		if owners, err := db.DeploymentOwners(o.ctx, bought[1].Org); err != nil || len(owners) != 0 {
+			t.Fatalf("Expected: no owner after reorg, Actual: %v %v", owners, err)
+		}
+	})
+}
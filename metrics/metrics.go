@@ -0,0 +1,135 @@
+// SPDX-License-Identifier: Apache-2.0
+
+package metrics
+
+import (
+	"net/http"
+
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/promauto"
+	"github.com/prometheus/client_golang/prometheus/promhttp"
+)
+
+const namespace = "radicle_cloud"
+
+var (
+	// EventsReceived counts contract events received from chain by type
+	EventsReceived = promauto.NewCounterVec(prometheus.CounterOpts{
+		Namespace: namespace,
+		Name:      "events_received_total",
+		Help:      "Contract events received from chain.",
+	}, []string{"type", "removed"})
+
+	// ChainReorgs counts reorgs of the chain expiries are measured on
+	ChainReorgs = promauto.NewCounter(prometheus.CounterOpts{
+		Namespace: namespace,
+		Name:      "chain_reorgs_total",
+		Help:      "Reorgs which orphaned recent heads of the chain expiries are measured on.",
+	})
+
+	// SubscriptionErrors counts dropped log subscriptions and dial failures
+	SubscriptionErrors = promauto.NewCounter(prometheus.CounterOpts{
+		Namespace: namespace,
+		Name:      "subscription_errors_total",
+		Help:      "Errors of the chain log subscription.",
+	})
+
+	// UnconfirmedLogs is the number of logs waiting for confirmations
+	UnconfirmedLogs = promauto.NewGauge(prometheus.GaugeOpts{
+		Namespace: namespace,
+		Name:      "unconfirmed_logs",
+		Help:      "Contract logs buffered until they reach the confirmation depth.",
+	})
+
+	// EventsProcessed counts processEvent outcomes by resulting status
+	EventsProcessed = promauto.NewCounterVec(prometheus.CounterOpts{
+		Namespace: namespace,
+		Name:      "events_processed_total",
+		Help:      "Outcomes of processing an event by resulting deployment status.",
+	}, []string{"status"})
+
+	// EventsDeadLettered counts events which ran out of retries
+	EventsDeadLettered = promauto.NewCounter(prometheus.CounterOpts{
+		Namespace: namespace,
+		Name:      "events_dead_lettered_total",
+		Help:      "Events moved to dead letters after exhausting their retries.",
+	})
+
+	// AnsibleDuration observes every ansible run by result
+	AnsibleDuration = promauto.NewHistogramVec(prometheus.HistogramOpts{
+		Namespace: namespace,
+		Name:      "ansible_duration_seconds",
+		Help:      "Duration of a single ansible setup run.",
+		Buckets:   prometheus.ExponentialBuckets(5, 2, 8),
+	}, []string{"result"})
+
+	// AnsibleRetries counts ansible runs that were retried
+	AnsibleRetries = promauto.NewCounter(prometheus.CounterOpts{
+		Namespace: namespace,
+		Name:      "ansible_retries_total",
+		Help:      "Ansible setup runs which failed and were retried.",
+	})
+
+	// ProviderDuration observes create and delete calls to cloud providers
+	ProviderDuration = promauto.NewHistogramVec(prometheus.HistogramOpts{
+		Namespace: namespace,
+		Name:      "provider_duration_seconds",
+		Help:      "Latency of cloud provider operations.",
+		Buckets:   prometheus.ExponentialBuckets(0.5, 2, 9),
+	}, []string{"provider", "op", "result"})
+
+	// DNSErrors counts failed calls to the DNS provider
+	DNSErrors = promauto.NewCounterVec(prometheus.CounterOpts{
+		Namespace: namespace,
+		Name:      "dns_errors_total",
+		Help:      "Failed DNS provider calls.",
+	}, []string{"provider", "op"})
+
+	// CurrentBlock is the block expiries are measured against
+	CurrentBlock = promauto.NewGauge(prometheus.GaugeOpts{
+		Namespace: namespace,
+		Name:      "current_block",
+		Help:      "Block the operator measures expiries against.",
+	})
+
+	// BlockInterval is the estimated average time between blocks
+	BlockInterval = promauto.NewGauge(prometheus.GaugeOpts{
+		Namespace: namespace,
+		Name:      "block_interval_seconds",
+		Help:      "Average block interval of the chain expiries are measured on.",
+	})
+
+	// LogPageSize is the number of blocks fetched per eth_getLogs call
+	LogPageSize = promauto.NewGaugeVec(prometheus.GaugeOpts{
+		Namespace: namespace,
+		Name:      "log_page_size_blocks",
+		Help:      "Blocks fetched per eth_getLogs call, shrinks while the provider rejects pages.",
+	}, []string{"source"})
+
+	// IsLeader is 1 while this replica holds the leader lock
+	IsLeader = promauto.NewGauge(prometheus.GaugeOpts{
+		Namespace: namespace,
+		Name:      "is_leader",
+		Help:      "Whether this replica is the leader.",
+	})
+
+	// ExpiryStateSize is the number of deployments waiting to expire
+	ExpiryStateSize = promauto.NewGauge(prometheus.GaugeOpts{
+		Namespace: namespace,
+		Name:      "expiry_state_size",
+		Help:      "Deployments tracked for expiry.",
+	})
+)
+
+// Handler serves all metrics in the Prometheus text format
+func Handler() http.Handler {
+	return promhttp.Handler()
+}
+
+// Result labels an operation by its error
+func Result(err error) string {
+	if err != nil {
+		return "error"
+	}
+	return "ok"
+}
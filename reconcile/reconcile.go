@@ -0,0 +1,208 @@
+// SPDX-License-Identifier: Apache-2.0
+
+package reconcile
+
+import (
+	"context"
+	"log"
+	"os"
+	"radicle-cloud/cloud"
+	"radicle-cloud/db"
+	"strings"
+	"time"
+)
+
+var l *log.Logger
+
+func init() {
+	l = log.New(os.Stderr, "[RECONCILE]	", log.Ldate|log.Ltime|log.Lshortfile)
+}
+
+// Drift is the difference between the deployments table and what actually
+// exists at cloud providers and in the DNS zone
+type Drift struct {
+	// OrphanServers exist at a provider without a deployment
+	OrphanServers map[string][]cloud.Server
+	// OrphanRecords exist in the zone without a deployment
+	OrphanRecords []cloud.Record
+	// MissingServers are allocated deployments without a server
+	MissingServers []db.Dep
+	// MissingRecords are running or suspended deployments without a matching A record
+	MissingRecords []db.Dep
+}
+
+// Empty returns true if nothing has drifted
+func (d *Drift) Empty() bool {
+	orphans := 0
+	for _, servers := range d.OrphanServers {
+		orphans += len(servers)
+	}
+	return orphans == 0 && len(d.OrphanRecords) == 0 &&
+		len(d.MissingServers) == 0 && len(d.MissingRecords) == 0
+}
+
+// Diff compares deployments against servers by provider and records in domain
+func Diff(deps []db.Dep, servers map[string][]cloud.Server, records []cloud.Record, domain string) Drift {
+	drift := Drift{OrphanServers: map[string][]cloud.Server{}}
+	orgs := map[string]db.Dep{}
+	for _, dep := range deps {
+		// a terminated deployment owns nothing anymore
+		if dep.Status != db.TerminatedStatus {
+			orgs[dep.Org] = dep
+		}
+	}
+
+	found := map[string]bool{}
+	for provider, srvs := range servers {
+		for _, srv := range srvs {
+			dep, ok := orgs[srv.Org]
+			switch {
+			case !ok:
+				drift.OrphanServers[provider] = append(drift.OrphanServers[provider], srv)
+			case dep.Provider == provider:
+				found[srv.Org] = true
+			case dep.Provider != "":
+				// org was moved to another provider and this one leaked
+				drift.OrphanServers[provider] = append(drift.OrphanServers[provider], srv)
+			}
+			// an empty provider means the server is being allocated right now
+		}
+	}
+
+	ips := map[string]string{}
+	suffix := "." + domain
+	for _, r := range records {
+		if !strings.HasSuffix(r.Name, suffix) {
+			continue
+		}
+		org := strings.TrimSuffix(r.Name, suffix)
+		// leave records which are not org subdomains alone
+		if !cloud.IsOrg(org) {
+			continue
+		}
+		if _, ok := orgs[org]; !ok {
+			drift.OrphanRecords = append(drift.OrphanRecords, r)
+			continue
+		}
+		ips[org] = r.IP
+	}
+
+	for _, dep := range deps {
+		switch dep.Status {
+		// terminating deployments are going away and terminated ones are gone
+		case db.InitialStatus, db.TerminatingStatus, db.TerminatedStatus:
+			continue
+		}
+		if dep.Provider != "" && !found[dep.Org] {
+			drift.MissingServers = append(drift.MissingServers, dep)
+		}
+		serving := dep.Status == db.RunningStatus || dep.Status == db.SuspendedStatus
+		if serving && dep.IP != "" && ips[dep.Org] != dep.IP {
+			drift.MissingRecords = append(drift.MissingRecords, dep)
+		}
+	}
+	return drift
+}
+
+// Check lists everything at providers and in the zone and diffs it with DB
+func Check(ctx context.Context) (Drift, error) {
+	servers := map[string][]cloud.Server{}
+	for _, p := range cloud.Providers() {
+		srvs, err := p.List(ctx)
+		if err != nil {
+			return Drift{}, err
+		}
+		servers[p.Name()] = srvs
+	}
+	records, err := cloud.ListDNS(ctx)
+	if err != nil {
+		return Drift{}, err
+	}
+	// list deployments last so that anything created meanwhile is known
+	deps, err := db.ListDeployments(ctx)
+	if err != nil {
+		return Drift{}, err
+	}
+	return Diff(deps, servers, records, cloud.Domain()), nil
+}
+
+// Repair deletes orphans and recreates missing A records. Missing servers
+// are only reported since provisioning belongs to the event pipeline.
+func Repair(ctx context.Context, drift Drift) {
+	for provider, srvs := range drift.OrphanServers {
+		p, ok := cloud.GetProvider(provider)
+		if !ok {
+			continue
+		}
+		for _, srv := range srvs {
+			if err := p.Delete(ctx, srv.Org); err != nil {
+				l.Printf("Failed to delete orphan server org=%s provider=%s err=%v\n", srv.Org, provider, err)
+				continue
+			}
+			l.Printf("Deleted orphan server org=%s provider=%s\n", srv.Org, provider)
+		}
+	}
+	for _, r := range drift.OrphanRecords {
+		org := strings.TrimSuffix(r.Name, "."+cloud.Domain())
+		if err := cloud.DeleteDNS(ctx, org); err != nil {
+			l.Printf("Failed to delete orphan record %s err=%v\n", r.Name, err)
+			continue
+		}
+		l.Printf("Deleted orphan record %s\n", r.Name)
+	}
+	for _, dep := range drift.MissingRecords {
+		// drop a record pointing to a stale ip before creating the right one
+		if err := cloud.DeleteDNS(ctx, dep.Org); err != nil {
+			l.Printf("Failed to delete stale record for org=%s err=%v\n", dep.Org, err)
+			continue
+		}
+		if err := cloud.CreateDNS(ctx, dep.Org, dep.IP); err != nil {
+			l.Printf("Failed to create missing record for org=%s err=%v\n", dep.Org, err)
+			continue
+		}
+		l.Printf("Created missing record for org=%s ip=%s\n", dep.Org, dep.IP)
+	}
+}
+
+// Report logs every drifted resource
+func Report(drift Drift) {
+	for provider, srvs := range drift.OrphanServers {
+		for _, srv := range srvs {
+			l.Printf("Orphan server org=%s provider=%s id=%s ip=%s\n", srv.Org, provider, srv.ID, srv.IP)
+		}
+	}
+	for _, r := range drift.OrphanRecords {
+		l.Printf("Orphan record %s ip=%s\n", r.Name, r.IP)
+	}
+	for _, dep := range drift.MissingServers {
+		l.Printf("Missing server org=%s provider=%s status=%s\n", dep.Org, dep.Provider, dep.Status)
+	}
+	for _, dep := range drift.MissingRecords {
+		l.Printf("Missing record org=%s ip=%s\n", dep.Org, dep.IP)
+	}
+}
+
+// Run periodically checks for drift and optionally repairs it until ctx is done
+func Run(ctx context.Context, interval time.Duration, repair bool) {
+	for {
+		drift, err := Check(ctx)
+		if ctx.Err() != nil {
+			return
+		}
+		if err != nil {
+			l.Println("Failed to check for drift", err)
+		} else if drift.Empty() {
+			l.Println("No drift detected")
+		} else {
+			Report(drift)
+			if repair {
+				Repair(ctx, drift)
+			}
+		}
+		select {
+		case <-ctx.Done():
+			return
+		case <-time.After(interval):
+		}
+	}
+}
@@ -0,0 +1,64 @@
+// SPDX-License-Identifier: Apache-2.0
+
+package reconcile
+
+import (
+	"radicle-cloud/cloud"
+	"radicle-cloud/db"
+	"testing"
+)
+
+const (
+	org1 = "0x0000000000000000000000000000000000000001"
+	org2 = "0x0000000000000000000000000000000000000002"
+	org3 = "0x0000000000000000000000000000000000000003"
+	org4 = "0x0000000000000000000000000000000000000004"
+)
+
+func TestDiff(t *testing.T) {
+	deps := []db.Dep{
+		{Org: org1, Provider: "hetzner", IP: "10.0.0.1", Status: db.RunningStatus},
+		{Org: org2, Provider: "hetzner", IP: "10.0.0.2", Status: db.RunningStatus},
+		{Org: org3, Provider: "", Status: db.InitialStatus},
+	}
+	servers := map[string][]cloud.Server{
+		"hetzner": {
+			{Org: org1, IP: "10.0.0.1"},
+			{Org: org3, IP: "10.0.0.3"}, // being allocated
+			{Org: org4, IP: "10.0.0.4"}, // leaked
+		},
+		"docker": {
+			{Org: org1, IP: "172.17.0.2"}, // moved to hetzner
+		},
+	}
+	records := []cloud.Record{
+		{Name: org1 + ".domain.tld", IP: "10.0.0.1"},
+		{Name: org2 + ".domain.tld", IP: "10.0.0.9"}, // stale ip
+		{Name: org4 + ".domain.tld", IP: "10.0.0.4"}, // leaked
+		{Name: "www.domain.tld", IP: "10.0.0.8"},
+	}
+
+	drift := Diff(deps, servers, records, "domain.tld")
+	t.Logf("%+v", drift)
+
+	if len(drift.OrphanServers["hetzner"]) != 1 || drift.OrphanServers["hetzner"][0].Org != org4 {
+		t.Errorf("Expected: %s to be an orphan at hetzner", org4)
+	}
+	if len(drift.OrphanServers["docker"]) != 1 || drift.OrphanServers["docker"][0].Org != org1 {
+		t.Errorf("Expected: %s to be an orphan at docker", org1)
+	}
+	if len(drift.OrphanRecords) != 1 || drift.OrphanRecords[0].Name != org4+".domain.tld" {
+		t.Errorf("Expected: only record of %s to be an orphan", org4)
+	}
+	if len(drift.MissingServers) != 1 || drift.MissingServers[0].Org != org2 {
+		t.Errorf("Expected: server of %s to be missing", org2)
+	}
+	if len(drift.MissingRecords) != 1 || drift.MissingRecords[0].Org != org2 {
+		t.Errorf("Expected: record of %s to be missing", org2)
+	}
+
+	clean := Diff(deps[:1], map[string][]cloud.Server{"hetzner": servers["hetzner"][:1]}, records[:1], "domain.tld")
+	if !clean.Empty() {
+		t.Errorf("Expected: no drift, Actual: %+v", clean)
+	}
+}
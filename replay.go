@@ -0,0 +1,209 @@
+// SPDX-License-Identifier: Apache-2.0
+
+package main
+
+import (
+	"context"
+	"flag"
+	"fmt"
+	"os"
+	"radicle-cloud/cloud"
+	"radicle-cloud/db"
+	"radicle-cloud/eth"
+	"sort"
+	"text/tabwriter"
+)
+
+// replayDiff is an org whose deployment in DB doesn't match the chain
+type replayDiff struct {
+	Org         string
+	DBExpiry    uint64
+	ChainExpiry uint64
+	Reason      string
+}
+
+// chainExpiries reconstructs the expiry of every org from events in chain
+// order, the latest event of a source decides and the furthest expiry among
+// sources wins like in db.MergedExpiry. It also returns the latest top-up of
+// every org.
+func chainExpiries(events []eth.Event) (map[string]uint64, map[string]eth.Event) {
+	latest := map[string]map[string]uint64{}
+	topUps := map[string]eth.Event{}
+	for _, e := range events {
+		if !e.Type.IsDeploymentEvent() {
+			continue
+		}
+		if latest[e.Org] == nil {
+			latest[e.Org] = map[string]uint64{}
+		}
+		latest[e.Org][e.Source] = e.Expiry
+		if e.Type == eth.TopUpEvent {
+			topUps[e.Org] = e
+		}
+	}
+	expiries := map[string]uint64{}
+	for org, bySource := range latest {
+		for _, expiry := range bySource {
+			if expiry > expiries[org] {
+				expiries[org] = expiry
+			}
+		}
+	}
+	return expiries, topUps
+}
+
+// diffDeployments compares expiries reconstructed from chain with deps at
+// block current
+func diffDeployments(expiries map[string]uint64, deps []db.Dep, current uint64) []replayDiff {
+	diffs := []replayDiff{}
+	stored := map[string]db.Dep{}
+	for _, dep := range deps {
+		// terminated deployments are only kept for their transitions
+		if dep.Status == db.TerminatedStatus {
+			continue
+		}
+		stored[dep.Org] = dep
+		if _, ok := expiries[dep.Org]; !ok {
+			diffs = append(diffs, replayDiff{Org: dep.Org, DBExpiry: dep.Expiry, Reason: "no events in range"})
+		}
+	}
+	for org, expiry := range expiries {
+		dep, ok := stored[org]
+		switch {
+		case !ok && expiry > current:
+			diffs = append(diffs, replayDiff{Org: org, ChainExpiry: expiry, Reason: "missing"})
+		case ok && expiry <= current:
+			diffs = append(diffs, replayDiff{Org: org, DBExpiry: dep.Expiry, ChainExpiry: expiry, Reason: "expired"})
+		case ok && dep.Expiry != expiry:
+			diffs = append(diffs, replayDiff{Org: org, DBExpiry: dep.Expiry, ChainExpiry: expiry, Reason: "expiry"})
+		}
+	}
+	sort.Slice(diffs, func(i, j int) bool { return diffs[i].Org < diffs[j].Org })
+	return diffs
+}
+
+// replaySource reads the events of source between from and to
+func replaySource(ctx context.Context, source eth.Source, from uint64, to uint64, pageSize uint64) ([]eth.Event, error) {
+	client, _, err := eth.ChainEndpoints(source.Chain).Dial(ctx)
+	if err != nil {
+		return nil, err
+	}
+	defer client.Close()
+	if to == 0 {
+		if to, err = client.BlockNumber(ctx); err != nil {
+			return nil, err
+		}
+		// unconfirmed logs could still be reorged away
+		if depth := eth.ConfirmationDepth(); depth > 0 && to >= depth {
+			to -= depth - 1
+		}
+	}
+	if from < source.StartBlock {
+		from = source.StartBlock
+	}
+
+	ec := make(chan eth.Event)
+	errc := make(chan error, 1)
+	go func() {
+		errc <- eth.Replay(ctx, client, source, from, to, pageSize, ec)
+		close(ec)
+	}()
+	events := []eth.Event{}
+	for e := range ec {
+		events = append(events, e)
+	}
+	return events, <-errc
+}
+
+func runReplay(args []string) {
+	fs := flag.NewFlagSet("replay", flag.ExitOnError)
+	from := fs.Uint64("from", 0, "first block, defaults to the start block of each source")
+	to := fs.Uint64("to", 0, "last block, defaults to the latest confirmed block")
+	only := fs.String("source", "", "replay only this chain:address")
+	pageSize := fs.Uint64("page", 2000, "blocks fetched per eth_getLogs call")
+	store := fs.Bool("store", false, "store the replayed events")
+	provision := fs.Bool("provision", false, "store the replayed events and provision deployments which are missing or behind")
+	fs.Usage = func() { fmt.Fprint(os.Stderr, usage) }
+	if err := fs.Parse(args); err != nil || fs.NArg() > 0 || (*to > 0 && *from > *to) {
+		fmt.Fprint(os.Stderr, usage)
+		os.Exit(2)
+	}
+	loadEnv()
+	db.Connect()
+	ctx := context.Background()
+
+	var err error
+	if *only != "" {
+		sources, err = eth.ParseSources(*only)
+	} else {
+		sources, err = eth.Sources()
+	}
+	if err != nil {
+		l.Fatal("Invalid sources ", err)
+	}
+
+	events := []eth.Event{}
+	for _, source := range sources {
+		replayed, err := replaySource(ctx, source, *from, *to, *pageSize)
+		if err != nil {
+			l.Fatal("Failed to replay ", source, " ", err)
+		}
+		events = append(events, replayed...)
+	}
+	current, err := eth.CurrentBlock(ctx, l1Endpoints())
+	if err != nil {
+		l.Fatal("Failed to get current block ", err)
+	}
+	deps, err := db.ListDeployments(ctx)
+	if err != nil {
+		l.Fatal("Failed to list deployments ", err)
+	}
+	expiries, topUps := chainExpiries(events)
+	diffs := diffDeployments(expiries, deps, current)
+
+	fmt.Printf("Replayed %d events of %d orgs, current block is %d\n", len(events), len(expiries), current)
+	w := tabwriter.NewWriter(os.Stdout, 0, 4, 2, ' ', 0)
+	fmt.Fprintln(w, "ORG\tDB EXPIRY\tCHAIN EXPIRY\tDIFFERENCE")
+	for _, d := range diffs {
+		fmt.Fprintf(w, "%s\t%d\t%d\t%s\n", d.Org, d.DBExpiry, d.ChainExpiry, d.Reason)
+	}
+	w.Flush()
+
+	if !*store && !*provision {
+		return
+	}
+	for i := range events {
+		if _, err := storeEvent(ctx, &events[i], &current); err != nil {
+			l.Fatal("Failed to store event ", err)
+		}
+	}
+	fmt.Printf("Stored %d events\n", len(events))
+	if !*provision {
+		return
+	}
+
+	// provisioning mustn't race a running operator
+	cloud.Setup()
+	l.Println("Waiting for leadership, stop the operator to continue")
+	lead, err := db.Campaign(ctx, leaderPollInterval)
+	if err != nil {
+		l.Fatal("Failed to acquire leadership ", err)
+	}
+	defer lead.Resign()
+	// the operator schedules expiries from DB when it starts again
+	stateEvents := make(chan db.Dep, len(diffs))
+	provisioned := 0
+	for _, d := range diffs {
+		e, ok := topUps[d.Org]
+		if !ok || (d.Reason != "missing" && d.Reason != "expiry") {
+			continue
+		}
+		if err := processEvent(ctx, e, stateEvents); err != nil {
+			l.Println("Failed to provision org", d.Org, err)
+			recordFailure(ctx, e, err)
+			continue
+		}
+		provisioned++
+	}
+	fmt.Printf("Provisioned %d deployments\n", provisioned)
+}
@@ -0,0 +1,51 @@
+// SPDX-License-Identifier: Apache-2.0
+
+package main
+
+import (
+	"radicle-cloud/db"
+	"radicle-cloud/eth"
+	"testing"
+)
+
+func TestReplayDiff(t *testing.T) {
+	events := []eth.Event{
+		{Org: "a", Expiry: 300, Type: eth.TopUpEvent, Source: "l2:eth"},
+		{Org: "a", Expiry: 500, Type: eth.TopUpEvent, Source: "l2:dai"},
+		// the stop of one source doesn't end what another one paid for
+		{Org: "a", Expiry: 120, Type: eth.DeploymentStoppedEvent, Source: "l2:eth"},
+		{Org: "b", Expiry: 400, Type: eth.TopUpEvent, Source: "l2:eth"},
+		{Org: "c", Expiry: 200, Type: eth.TopUpEvent, Source: "l2:eth"},
+		{Org: "c", Expiry: 110, Type: eth.DeploymentStoppedEvent, Source: "l2:eth"},
+		{Org: "d", Expiry: 90, Type: eth.TopUpEvent, Source: "l2:eth"},
+		{Rate: 2, Type: eth.RateChangedEvent, Source: "l2:eth"},
+	}
+	expiries, topUps := chainExpiries(events)
+	if len(expiries) != 4 || expiries["a"] != 500 || expiries["c"] != 110 {
+		t.Fatalf("Unexpected expiries %v", expiries)
+	}
+	if topUps["a"].Expiry != 500 || topUps["c"].Expiry != 200 {
+		t.Fatalf("Unexpected top-ups %v", topUps)
+	}
+
+	deps := []db.Dep{
+		{Org: "a", Expiry: 300},
+		{Org: "c", Expiry: 200},
+		{Org: "e", Expiry: 700},
+	}
+	diffs := diffDeployments(expiries, deps, 150)
+	expected := []replayDiff{
+		{Org: "a", DBExpiry: 300, ChainExpiry: 500, Reason: "expiry"},
+		{Org: "b", ChainExpiry: 400, Reason: "missing"},
+		{Org: "c", DBExpiry: 200, ChainExpiry: 110, Reason: "expired"},
+		{Org: "e", DBExpiry: 700, Reason: "no events in range"},
+	}
+	if len(diffs) != len(expected) {
+		t.Fatalf("Expected: %v, Actual: %v", expected, diffs)
+	}
+	for i := range expected {
+		if diffs[i] != expected[i] {
+			t.Fatalf("Expected: %v, Actual: %v", expected[i], diffs[i])
+		}
+	}
+}
@@ -0,0 +1,29 @@
+// SPDX-License-Identifier: Apache-2.0
+
+package utils
+
+import "time"
+
+// Backoff computes exponentially growing delays between attempts
+type Backoff struct {
+	Base time.Duration
+	Max  time.Duration
+}
+
+// Delay returns the delay after attempt (starting at 1) has failed
+func (b Backoff) Delay(attempt int) time.Duration {
+	if attempt < 1 {
+		attempt = 1
+	}
+	d := b.Base
+	for i := 1; i < attempt; i++ {
+		d *= 2
+		if d >= b.Max || d <= 0 {
+			return b.Max
+		}
+	}
+	if d > b.Max {
+		return b.Max
+	}
+	return d
+}
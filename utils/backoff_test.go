@@ -0,0 +1,26 @@
+// SPDX-License-Identifier: Apache-2.0
+
+package utils
+
+import (
+	"testing"
+	"time"
+)
+
+func TestBackoff(t *testing.T) {
+	b := Backoff{Base: time.Second, Max: time.Minute}
+	expected := map[int]time.Duration{
+		0:   time.Second,
+		1:   time.Second,
+		2:   2 * time.Second,
+		3:   4 * time.Second,
+		6:   32 * time.Second,
+		7:   time.Minute,
+		100: time.Minute,
+	}
+	for attempt, delay := range expected {
+		if actual := b.Delay(attempt); actual != delay {
+			t.Errorf("Attempt %d Expected: %v, Actual: %v\n", attempt, delay, actual)
+		}
+	}
+}
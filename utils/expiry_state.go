@@ -93,6 +93,11 @@ func (s *ExpiryState) AddOrUpdateDep(dep db.Dep) {
 	}
 }
 
+// Len returns the number of deployments in state
+func (s *ExpiryState) Len() int {
+	return len(s.orgToBlock)
+}
+
 // Peek returns the smallest block number in heap
 func (s *ExpiryState) Peek() (uint64, bool) {
 	iblock, ok := s.blocks.Peek()